@@ -3,6 +3,7 @@ module github.com/rom8726/testy/v2
 go 1.25
 
 require (
+	github.com/go-sql-driver/mysql v1.8.0
 	github.com/google/uuid v1.6.0
 	github.com/julienschmidt/httprouter v1.3.0
 	github.com/kinbiko/jsonassert v1.2.0
@@ -11,7 +12,4 @@ require (
 	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	filippo.io/edwards25519 v1.1.0 // indirect
-	github.com/go-sql-driver/mysql v1.8.0 // indirect
-)
+require filippo.io/edwards25519 v1.1.0 // indirect
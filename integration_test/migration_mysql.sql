@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS products (
+    id INT NOT NULL AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    price DECIMAL(10, 2) NOT NULL DEFAULT 0.00,
+    attributes JSON NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INT NOT NULL AUTO_INCREMENT,
+    message TEXT NOT NULL,
+    PRIMARY KEY (id)
+);
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/julienschmidt/httprouter"
+)
+
+// NewMySQLServer creates a small product API backed by MySQL
+func NewMySQLServer(connStr string) *Server {
+	router := httprouter.New()
+
+	type product struct {
+		ID    int64   `json:"id"`
+		Name  string  `json:"name"`
+		Price float64 `json:"price"`
+	}
+
+	router.POST("/products", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		writer.Header().Set("Content-Type", "application/json")
+
+		defer func() {
+			if r := recover(); r != nil {
+				writer.WriteHeader(http.StatusInternalServerError)
+				fmt.Println(r)
+			}
+		}()
+
+		db, err := sql.Open("mysql", connStr)
+		if err != nil {
+			panic(err)
+		}
+		defer db.Close()
+
+		var req product
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		res, err := db.ExecContext(request.Context(), `INSERT INTO products (name, price) VALUES (?, ?)`, req.Name, req.Price)
+		if err != nil {
+			panic(err)
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = db.ExecContext(request.Context(), `INSERT INTO audit_log (message) VALUES (?)`, "product "+req.Name+" created")
+		if err != nil {
+			panic(err)
+		}
+
+		writer.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(writer).Encode(map[string]int64{"id": id})
+	})
+
+	router.GET("/products/:id", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		writer.Header().Set("Content-Type", "application/json")
+
+		defer func() {
+			if r := recover(); r != nil {
+				writer.WriteHeader(http.StatusInternalServerError)
+				fmt.Println(r)
+			}
+		}()
+
+		db, err := sql.Open("mysql", connStr)
+		if err != nil {
+			panic(err)
+		}
+		defer db.Close()
+
+		var p product
+		err = db.QueryRowContext(request.Context(), `SELECT id, name, price FROM products WHERE id = ?`, params.ByName("id")).
+			Scan(&p.ID, &p.Name, &p.Price)
+		if err == sql.ErrNoRows {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			panic(err)
+		}
+
+		_ = json.NewEncoder(writer).Encode(p)
+	})
+
+	return &Server{Router: router}
+}
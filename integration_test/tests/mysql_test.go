@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/rom8726/pgfixtures"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/rom8726/testy/v2"
+
+	"integration_test/server"
+)
+
+func TestMySQL(t *testing.T) {
+	ctx := context.Background()
+
+	// Start MySQL container with testcontainers
+	mysqlContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "mysql:8.0",
+			ExposedPorts: []string{"3306/tcp"},
+			Env: map[string]string{
+				"MYSQL_ROOT_PASSWORD": "password",
+				"MYSQL_DATABASE":      "db",
+			},
+			WaitingFor: wait.ForLog("port: 3306  MySQL Community Server").
+				WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to start mysql container: %v", err)
+	}
+	defer func() {
+		if err := mysqlContainer.Terminate(ctx); err != nil {
+			t.Fatalf("failed to terminate mysql container: %v", err)
+		}
+	}()
+
+	host, err := mysqlContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("failed to get container host: %v", err)
+	}
+	port, err := mysqlContainer.MappedPort(ctx, "3306/tcp")
+	if err != nil {
+		t.Fatalf("failed to get container port: %v", err)
+	}
+
+	connStr := fmt.Sprintf("root:password@tcp(%s:%s)/db?multiStatements=true", host, port.Port())
+
+	// Apply migration
+	_, testFile, _, _ := runtime.Caller(0)
+	testDir := filepath.Dir(testFile)
+	migrationPath := filepath.Join(testDir, "..", "migration_mysql.sql")
+	if err := applyMySQLMigration(ctx, connStr, migrationPath); err != nil {
+		t.Fatalf("failed to apply migration: %v", err)
+	}
+
+	srv := server.NewMySQLServer(connStr)
+
+	cfg := testy.Config{
+		Handler:     srv.Router,
+		DBType:      pgfixtures.MySQL,
+		CasesDir:    filepath.Join(testDir, "mysql", "cases"),
+		FixturesDir: filepath.Join(testDir, "mysql", "fixtures"),
+		ConnStr:     connStr,
+	}
+
+	testy.Run(t, &cfg)
+}
+
+// applyMySQLMigration applies the migration SQL file, waiting for MySQL to
+// accept connections first
+func applyMySQLMigration(ctx context.Context, connStr, migrationPath string) error {
+	db, err := sql.Open("mysql", connStr)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	for {
+		if err = db.PingContext(ctx); err == nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to ping database: %w", err)
+		case <-time.After(time.Second):
+		}
+	}
+
+	migrationSQL, err := os.ReadFile(migrationPath)
+	if err != nil {
+		return fmt.Errorf("failed to read migration file: %w", err)
+	}
+
+	if _, err := db.ExecContext(ctx, string(migrationSQL)); err != nil {
+		return fmt.Errorf("failed to execute migration: %w", err)
+	}
+
+	return nil
+}
@@ -16,47 +16,28 @@ import (
 // - "{{var}} >= number"
 // - "{{var}} <= number"
 // - "{{var}}" (truthy check)
+// - "{{status}} == active && {{n}} > 1" (placeholders combined with operators)
+// - "page + 1 > 2 && status == 'active'" (expression without placeholders)
 func EvaluateCondition(condition string, ctx map[string]any) (bool, error) {
+	return EvaluateConditionWithOptions(condition, ctx, DefaultRenderOptions())
+}
+
+// EvaluateConditionWithOptions evaluates a conditional expression, rendering
+// placeholders with the given options. Placeholders are typed operands and
+// bare words next to them are string values; without placeholders bare words
+// are variables. An unresolved placeholder or variable is an error in every
+// render mode, so that a typo does not silently change the outcome
+func EvaluateConditionWithOptions(condition string, ctx map[string]any, opts RenderOptions) (bool, error) {
 	if condition == "" {
 		return true, nil
 	}
 
-	// First, render the condition to replace placeholders
-	rendered := RenderTemplate(condition, ctx)
-
-	// Parse operators
-	operators := []string{"==", "!=", ">=", "<=", ">", "<"}
-
-	for _, op := range operators {
-		if strings.Contains(rendered, op) {
-			parts := strings.SplitN(rendered, op, 2)
-			if len(parts) != 2 {
-				return false, fmt.Errorf("invalid condition format: %s", condition)
-			}
-
-			left := strings.TrimSpace(parts[0])
-			right := strings.TrimSpace(parts[1])
-
-			return evaluateComparison(left, op, right)
-		}
+	val, err := evalExpression(condition, ctx, opts, strings.Contains(condition, "{{"))
+	if err != nil {
+		return false, fmt.Errorf("invalid condition %s: %w", condition, err)
 	}
 
-	// No operator found - check if value is truthy
-	return isTruthy(rendered), nil
-}
-
-// evaluateComparison compares two values using the given operator
-func evaluateComparison(left, operator, right string) (bool, error) {
-	// Try numeric comparison first
-	leftNum, leftIsNum := parseNumber(left)
-	rightNum, rightIsNum := parseNumber(right)
-
-	if leftIsNum && rightIsNum {
-		return compareNumbers(leftNum, operator, rightNum)
-	}
-
-	// String comparison
-	return compareStrings(left, operator, right)
+	return truthy(val), nil
 }
 
 // compareNumbers compares two numbers
@@ -95,15 +76,6 @@ func compareStrings(left, operator, right string) (bool, error) {
 	}
 }
 
-// parseNumber tries to parse a string as a number
-func parseNumber(s string) (float64, bool) {
-	s = strings.TrimSpace(s)
-	if num, err := strconv.ParseFloat(s, 64); err == nil {
-		return num, true
-	}
-	return 0, false
-}
-
 // isTruthy checks if a value is truthy
 func isTruthy(s string) bool {
 	s = strings.TrimSpace(s)
@@ -136,6 +108,12 @@ func ShouldExecuteStep(step Step, ctx map[string]any) (bool, error) {
 
 // ExpandLoop expands a loop configuration into individual items
 func ExpandLoop(loop *LoopConfig, ctx map[string]any) ([]map[string]any, error) {
+	return ExpandLoopWithOptions(loop, ctx, DefaultRenderOptions())
+}
+
+// ExpandLoopWithOptions expands a loop configuration, rendering item
+// placeholders with the given options
+func ExpandLoopWithOptions(loop *LoopConfig, ctx map[string]any, opts RenderOptions) ([]map[string]any, error) {
 	if loop == nil {
 		return nil, nil
 	}
@@ -143,10 +121,17 @@ func ExpandLoop(loop *LoopConfig, ctx map[string]any) ([]map[string]any, error)
 	var items []any
 
 	// Use items list if provided
-	if len(loop.Items) > 0 {
+	if list, ok, err := loopItemsFromExpression(loop.Items, ctx, opts); err != nil {
+		return nil, err
+	} else if ok {
+		items = list
+	} else if len(loop.Items) > 0 {
 		// Render items to expand any placeholders
 		for _, item := range loop.Items {
-			rendered := RenderAny(item, ctx)
+			rendered, err := renderValue(item, ctx, opts)
+			if err != nil {
+				return nil, err
+			}
 			items = append(items, rendered)
 		}
 	} else if loop.Range != nil {
@@ -190,9 +175,40 @@ func ExpandLoop(loop *LoopConfig, ctx map[string]any) ([]map[string]any, error)
 	return contexts, nil
 }
 
-// ParseJSONPath extracts a value from a map using a JSON path
-// Supports: "field", "nested.field", "array[0]", "nested.array[0].field"
-func ParseJSONPath(path string, data map[string]any) (any, error) {
+// loopItemsFromExpression evaluates items given as a single placeholder
+// that yields a list: items: "{{ ids }}" or "{{ users | split(',') }}"
+func loopItemsFromExpression(items LoopItems, ctx map[string]any, opts RenderOptions) ([]any, bool, error) {
+	if len(items) != 1 {
+		return nil, false, nil
+	}
+
+	expr, ok := items[0].(string)
+	if !ok {
+		return nil, false, nil
+	}
+
+	m := placeholderRe.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil || m[0] != strings.TrimSpace(expr) {
+		return nil, false, nil
+	}
+
+	val, err := evalPlaceholder(m[1], ctx, opts)
+	if err != nil {
+		if opts.Mode == RenderModeStrict {
+			return nil, false, err
+		}
+
+		return nil, false, nil
+	}
+
+	list, ok := val.([]any)
+
+	return list, ok, nil
+}
+
+// ParseJSONPath extracts a value from decoded JSON using a JSON path
+// Supports: "field", "nested.field", "array[0]", "nested.array[0].field", "[0].field"
+func ParseJSONPath(path string, data any) (any, error) {
 	if path == "" {
 		return data, nil
 	}
@@ -104,6 +104,20 @@ func TestEvaluateCondition(t *testing.T) {
 	}
 }
 
+func TestEvaluateConditionWithOptions_StrictMode(t *testing.T) {
+	opts := RenderOptions{Mode: RenderModeStrict}
+
+	result, err := EvaluateConditionWithOptions("{{status}} == active", map[string]any{"status": "active"}, opts)
+	if err != nil || !result {
+		t.Errorf("Expected true without error, got %v, %v", result, err)
+	}
+
+	_, err = EvaluateConditionWithOptions("{{missing}} == active", map[string]any{}, opts)
+	if err == nil {
+		t.Error("Expected error for unresolved placeholder in strict mode")
+	}
+}
+
 func TestCompareNumbers(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1,6 +1,9 @@
 package internal
 
 import (
+	"database/sql"
+	"net/http"
+
 	"github.com/rom8726/pgfixtures"
 )
 
@@ -11,6 +14,22 @@ type Config struct {
 	FixturesDir string
 	Mocks       []*MockInstance
 
+	// Database is used instead of opening ConnStr for every case
+	Database Database
+
+	// Isolation is the isolation mode of cases that do not set their own
+	Isolation IsolationMode
+	// SQLDB is the pool case transactions are started on
+	SQLDB *sql.DB
+	// TxHandler builds the handler under test bound to a case transaction
+	TxHandler func(tx *sql.Tx) (http.Handler, error)
+	// Snapshot is the PostgreSQL database restored after snapshot-isolated cases
+	Snapshot string
+
+	RenderOptions RenderOptions
+	// FakerSeed seeds the faker source of every case together with its index
+	FakerSeed int64
+
 	BeforeReq func() error
 	AfterReq  func() error
 }
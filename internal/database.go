@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/rom8726/pgfixtures"
+)
+
+// SQLite selects the SQLite dialect. No SQLite driver is bundled, so it is
+// used together with a caller-provided *sql.DB
+const SQLite pgfixtures.DatabaseType = "sqlite"
+
+// Database runs fixtures, SQL hooks and dbChecks. SQLDatabase adapts any
+// database/sql driver; clients outside database/sql can implement it directly
+type Database interface {
+	// Exec runs a statement that returns no rows
+	Exec(ctx context.Context, query string, args ...any) error
+	// Query runs a query and returns its rows as column to value maps
+	Query(ctx context.Context, query string, args ...any) ([]map[string]any, error)
+	// LoadFixture clears the tables listed in a fixture file and inserts its rows
+	LoadFixture(ctx context.Context, path string) error
+}
+
+// sqlConn is the part of *sql.DB, *sql.Conn and *sql.Tx used by SQLDatabase
+type sqlConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// SQLDatabase is a Database backed by a database/sql connection
+type SQLDatabase struct {
+	conn    sqlConn
+	dbType  pgfixtures.DatabaseType
+	connStr string
+}
+
+// NewSQLDatabase wraps db. When connStr is set, PostgreSQL and MySQL fixtures
+// are loaded with pgfixtures; otherwise the built-in loader is used
+func NewSQLDatabase(db *sql.DB, dbType pgfixtures.DatabaseType, connStr string) *SQLDatabase {
+	return &SQLDatabase{conn: db, dbType: dbType, connStr: connStr}
+}
+
+// newTxDatabase wraps a case transaction. Fixtures are loaded inside the
+// transaction by the built-in loader
+func newTxDatabase(tx *sql.Tx, dbType pgfixtures.DatabaseType) *SQLDatabase {
+	return &SQLDatabase{conn: tx, dbType: dbType}
+}
+
+// Exec runs a statement that returns no rows
+func (d *SQLDatabase) Exec(ctx context.Context, query string, args ...any) error {
+	_, err := d.conn.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+// Query runs a query and decodes its rows
+func (d *SQLDatabase) Query(ctx context.Context, query string, args ...any) ([]map[string]any, error) {
+	rows, err := d.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]any, 0)
+
+	for rows.Next() {
+		row := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range ptrs {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		m := make(map[string]any, len(cols))
+		for i, col := range cols {
+			m[col] = decodeColumnValue(row[i], colTypes[i].DatabaseTypeName())
+		}
+
+		results = append(results, m)
+	}
+
+	return results, rows.Err()
+}
+
+// LoadFixture loads a fixture file
+func (d *SQLDatabase) LoadFixture(ctx context.Context, path string) error {
+	if d.connStr != "" && (d.dbType == pgfixtures.PostgreSQL || d.dbType == pgfixtures.MySQL) {
+		return pgfixtures.Load(ctx, &pgfixtures.Config{
+			FilePath:     path,
+			ConnStr:      d.connStr,
+			DatabaseType: d.dbType,
+			Truncate:     true,
+			ResetSeq:     true,
+		})
+	}
+
+	return loadFixtureFile(ctx, d, d.dbType, path)
+}
+
+// bindVar returns the bind parameter placeholder for the n-th (1-based)
+// argument: $1 for PostgreSQL, ? otherwise
+func bindVar(dbType pgfixtures.DatabaseType, n int) string {
+	if dbType == pgfixtures.PostgreSQL || dbType == "" {
+		return "$" + strconv.Itoa(n)
+	}
+
+	return "?"
+}
+
+// quoteIdent quotes a possibly schema-qualified identifier for the dialect
+func quoteIdent(dbType pgfixtures.DatabaseType, name string) string {
+	quote := `"`
+	if dbType == pgfixtures.MySQL {
+		quote = "`"
+	}
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rom8726/pgfixtures"
+)
+
+// recordingDB is a Database that records statements and answers queries
+// with fixed rows
+type recordingDB struct {
+	execs []string
+	args  [][]any
+	rows  []map[string]any
+}
+
+func (d *recordingDB) Exec(_ context.Context, query string, args ...any) error {
+	d.execs = append(d.execs, query)
+	d.args = append(d.args, args)
+
+	return nil
+}
+
+func (d *recordingDB) Query(_ context.Context, query string, args ...any) ([]map[string]any, error) {
+	return d.rows, nil
+}
+
+func (d *recordingDB) LoadFixture(ctx context.Context, path string) error {
+	return loadFixtureFile(ctx, d, SQLite, path)
+}
+
+func TestBindVar(t *testing.T) {
+	if got := bindVar(pgfixtures.PostgreSQL, 2); got != "$2" {
+		t.Errorf("Expected $2, got %s", got)
+	}
+	if got := bindVar(pgfixtures.MySQL, 2); got != "?" {
+		t.Errorf("Expected ?, got %s", got)
+	}
+	if got := bindVar(SQLite, 2); got != "?" {
+		t.Errorf("Expected ?, got %s", got)
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	if got := quoteIdent(pgfixtures.PostgreSQL, "public.users"); got != `"public"."users"` {
+		t.Errorf("Expected quoted schema and table, got %s", got)
+	}
+	if got := quoteIdent(pgfixtures.MySQL, "users"); got != "`users`" {
+		t.Errorf("Expected backtick-quoted table, got %s", got)
+	}
+}
+
+func TestLoadFixtureFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.yml")
+	fixture := `
+users:
+  - id: 1
+    name: Alice
+    created_at: $eval(CURRENT_TIMESTAMP)
+orders:
+  - id: 10
+    user_id: 1
+    meta: {source: web}
+`
+	if err := os.WriteFile(path, []byte(fixture), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	db := &recordingDB{}
+	if err := loadFixtureFile(context.Background(), db, SQLite, path); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	expectedExecs := []string{
+		`DELETE FROM "orders"`,
+		`DELETE FROM "users"`,
+		`INSERT INTO "users" ("created_at", "id", "name") VALUES ((CURRENT_TIMESTAMP), ?, ?)`,
+		`INSERT INTO "orders" ("id", "meta", "user_id") VALUES (?, ?, ?)`,
+	}
+	if !reflect.DeepEqual(db.execs, expectedExecs) {
+		t.Errorf("Expected statements %q, got %q", expectedExecs, db.execs)
+	}
+
+	expectedArgs := []any{10, `{"source":"web"}`, 1}
+	if !reflect.DeepEqual(db.args[3], expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, db.args[3])
+	}
+}
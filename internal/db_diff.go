@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/rom8726/pgfixtures"
+)
+
+// DBDiffTable describes the rows of a table expected to change in a step.
+// A category left empty expects no such changes
+type DBDiffTable struct {
+	Table    string           `yaml:"table"`
+	Key      []string         `yaml:"key,omitempty"`      // columns identifying a row, default [id]
+	Inserted []map[string]any `yaml:"inserted,omitempty"` // new rows
+	Updated  []map[string]any `yaml:"updated,omitempty"`  // changed rows, with their new values
+	Deleted  []map[string]any `yaml:"deleted,omitempty"`  // removed rows, with their old values
+}
+
+// dbTableChanges holds the rows that changed in a table
+type dbTableChanges struct {
+	inserted, updated, deleted []map[string]any
+}
+
+// SnapshotTables reads every row of the tables of a dbDiff
+func SnapshotTables(ctx context.Context, db Database, dbType pgfixtures.DatabaseType, specs []DBDiffTable) (map[string][]map[string]any, error) {
+	snapshot := make(map[string][]map[string]any, len(specs))
+
+	for _, spec := range specs {
+		rows, err := db.Query(ctx, "SELECT * FROM "+quoteIdent(dbType, spec.Table))
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", spec.Table, err)
+		}
+
+		actual, err := json.Marshal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", spec.Table, err)
+		}
+		if snapshot[spec.Table], err = decodeRows(actual); err != nil {
+			return nil, fmt.Errorf("table %s: %w", spec.Table, err)
+		}
+	}
+
+	return snapshot, nil
+}
+
+// AssertDBDiff compares the rows changed since before with the dbDiff of a step
+func AssertDBDiff(
+	t *testing.T,
+	db Database,
+	dbType pgfixtures.DatabaseType,
+	specs []DBDiffTable,
+	before map[string][]map[string]any,
+	ctx map[string]any,
+	opts RenderOptions,
+) {
+	t.Helper()
+	const op = "AssertDBDiff"
+
+	after, err := SnapshotTables(t.Context(), db, dbType, specs)
+	if err != nil {
+		dbErr := NewError(ErrDatabase, op, "failed to read tables after the request").
+			WithContext("error", err.Error())
+		t.Fatalf("%+v", dbErr)
+	}
+
+	for _, spec := range specs {
+		diffs, err := dbDiffMismatches(spec, before[spec.Table], after[spec.Table], ctx, opts)
+		if err != nil {
+			dbErr := NewError(ErrInvalidInput, op, "invalid dbDiff").
+				WithContext("table", spec.Table).
+				WithContext("error", err.Error())
+			t.Fatalf("%+v", dbErr)
+		}
+
+		for _, diff := range diffs {
+			t.Errorf("dbDiff %s: %s", spec.Table, diff)
+		}
+	}
+}
+
+// dbDiffMismatches describes how the changes of a table differ from the
+// expected inserted, updated and deleted rows
+func dbDiffMismatches(spec DBDiffTable, before, after []map[string]any, ctx map[string]any, opts RenderOptions) ([]string, error) {
+	key := spec.Key
+	if len(key) == 0 {
+		key = []string{"id"}
+	}
+
+	changes, err := tableChanges(before, after, key)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := []struct {
+		name     string
+		expected []map[string]any
+		actual   []map[string]any
+	}{
+		{"inserted", spec.Inserted, changes.inserted},
+		{"updated", spec.Updated, changes.updated},
+		{"deleted", spec.Deleted, changes.deleted},
+	}
+
+	var diffs []string
+	for _, c := range categories {
+		expected, err := renderExpectedRows(c.expected, ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.name, err)
+		}
+
+		if len(c.actual) != len(expected) {
+			b, _ := json.Marshal(c.actual)
+			diffs = append(diffs, fmt.Sprintf("expected %d %s rows, got %d: %s", len(expected), c.name, len(c.actual), b))
+
+			continue
+		}
+		for _, diff := range matchRows(c.actual, expected, true) {
+			diffs = append(diffs, c.name+": "+diff)
+		}
+	}
+
+	return diffs, nil
+}
+
+// tableChanges pairs the rows of two snapshots by key
+func tableChanges(before, after []map[string]any, key []string) (dbTableChanges, error) {
+	var changes dbTableChanges
+
+	beforeByKey := make(map[string]map[string]any, len(before))
+	for _, row := range before {
+		k, err := rowKey(row, key)
+		if err != nil {
+			return changes, err
+		}
+		beforeByKey[k] = row
+	}
+
+	seen := make(map[string]bool, len(after))
+	for _, row := range after {
+		k, err := rowKey(row, key)
+		if err != nil {
+			return changes, err
+		}
+		seen[k] = true
+
+		old, ok := beforeByKey[k]
+		switch {
+		case !ok:
+			changes.inserted = append(changes.inserted, row)
+		case len(rowDiffs(row, old, false)) > 0:
+			changes.updated = append(changes.updated, row)
+		}
+	}
+
+	for _, row := range before {
+		k, _ := rowKey(row, key)
+		if !seen[k] {
+			changes.deleted = append(changes.deleted, row)
+		}
+	}
+
+	return changes, nil
+}
+
+// rowKey returns the values of the key columns of a row
+func rowKey(row map[string]any, key []string) (string, error) {
+	values := make([]any, len(key))
+	for i, col := range key {
+		val, ok := row[col]
+		if !ok {
+			return "", fmt.Errorf("key column %s not found", col)
+		}
+		values[i] = val
+	}
+
+	b, err := json.Marshal(values)
+
+	return string(b), err
+}
+
+// renderExpectedRows renders expected rows and normalises them to JSON types
+func renderExpectedRows(rows []map[string]any, ctx map[string]any, opts RenderOptions) ([]map[string]any, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	list := make([]any, len(rows))
+	for i, row := range rows {
+		list[i] = map[string]any(row)
+	}
+
+	rendered, err := renderValue(list, ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(rendered)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRows(b)
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDBDiffMismatches(t *testing.T) {
+	before := []map[string]any{
+		{"id": float64(1), "name": "Alice", "active": true},
+		{"id": float64(2), "name": "Bob", "active": true},
+		{"id": float64(3), "name": "Carol", "active": true},
+	}
+	after := []map[string]any{
+		{"id": float64(1), "name": "Alice", "active": true},
+		{"id": float64(2), "name": "Bob", "active": false},
+		{"id": float64(4), "name": "Dave", "active": true},
+	}
+	ctx := map[string]any{"newId": 4}
+
+	tests := []struct {
+		name     string
+		spec     DBDiffTable
+		wantDiff string
+	}{
+		{
+			name: "expected changes",
+			spec: DBDiffTable{
+				Table:    "users",
+				Inserted: []map[string]any{{"id": "{{newId}}", "name": "Dave"}},
+				Updated:  []map[string]any{{"id": 2, "active": false}},
+				Deleted:  []map[string]any{{"name": "Carol"}},
+			},
+		},
+		{
+			name: "unexpected update",
+			spec: DBDiffTable{
+				Table:    "users",
+				Inserted: []map[string]any{{"name": "Dave"}},
+				Deleted:  []map[string]any{{"id": 3}},
+			},
+			wantDiff: "expected 0 updated rows, got 1",
+		},
+		{
+			name: "wrong inserted values",
+			spec: DBDiffTable{
+				Table:    "users",
+				Inserted: []map[string]any{{"name": "Eve"}},
+				Updated:  []map[string]any{{"id": 2}},
+				Deleted:  []map[string]any{{"id": 3}},
+			},
+			wantDiff: "inserted: no row matches",
+		},
+		{
+			name: "custom key",
+			spec: DBDiffTable{
+				Table:    "users",
+				Key:      []string{"name"},
+				Inserted: []map[string]any{{"name": "Dave"}},
+				Updated:  []map[string]any{{"name": "Bob", "active": map[string]any{"operator": "equals", "value": false}}},
+				Deleted:  []map[string]any{{"name": "Carol"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := dbDiffMismatches(tt.spec, before, after, ctx, DefaultRenderOptions())
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			if tt.wantDiff == "" {
+				if len(diffs) != 0 {
+					t.Errorf("Expected no differences, got %v", diffs)
+				}
+				return
+			}
+			if !strings.Contains(strings.Join(diffs, "\n"), tt.wantDiff) {
+				t.Errorf("Expected a difference containing %q, got %v", tt.wantDiff, diffs)
+			}
+		})
+	}
+}
+
+func TestDBDiffMismatches_MissingKey(t *testing.T) {
+	rows := []map[string]any{{"name": "Alice"}}
+	_, err := dbDiffMismatches(DBDiffTable{Table: "users"}, rows, rows, nil, DefaultRenderOptions())
+	if err == nil || !strings.Contains(err.Error(), "key column id not found") {
+		t.Errorf("Expected missing key error, got %v", err)
+	}
+}
@@ -3,132 +3,282 @@ package internal
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	_ "github.com/go-sql-driver/mysql"
 	"github.com/kinbiko/jsonassert"
 	_ "github.com/lib/pq"
 	"github.com/rom8726/pgfixtures"
 )
 
-// LoadFixturesFromList loads all fixtures specified in the test case
-func LoadFixturesFromList(t *testing.T, dbType pgfixtures.DatabaseType, connStr, fixturesDir string, fixtures []string) {
-	t.Helper()
-
-	for _, fixtureName := range fixtures {
-		fixtureName += ".yml"
-		fixturePath := filepath.Join(fixturesDir, fixtureName)
-		LoadFixtureFile(t, dbType, connStr, fixturePath)
+// DriverName returns the database/sql driver for a database type
+func DriverName(dbType pgfixtures.DatabaseType) string {
+	if dbType == pgfixtures.MySQL {
+		return "mysql"
 	}
+
+	return "postgres"
+}
+
+// OpenDB opens a connection pool using the driver for dbType
+func OpenDB(dbType pgfixtures.DatabaseType, connStr string) (*sql.DB, error) {
+	return sql.Open(DriverName(dbType), connStr)
 }
 
-// LoadFixtureFile loads a single fixture file
-func LoadFixtureFile(t *testing.T, dbType pgfixtures.DatabaseType, connStr, fixturePath string) {
+// LoadFixtures loads the fixtures of a test case into db
+func LoadFixtures(t *testing.T, db Database, fixturesDir string, fixtures []string) {
 	t.Helper()
+	const op = "LoadFixtures"
 
-	cfg := &pgfixtures.Config{
-		FilePath:     fixturePath,
-		ConnStr:      connStr,
-		DatabaseType: dbType,
-		Truncate:     true,
-		ResetSeq:     true,
-		DryRun:       false,
+	if len(fixtures) > 0 && db == nil {
+		dbErr := NewError(ErrDatabase, op, "fixtures require a database").
+			WithContext("fixtures", strings.Join(fixtures, ", "))
+		t.Fatalf("%+v", dbErr)
 	}
 
-	err := pgfixtures.Load(t.Context(), cfg)
-	if err != nil {
-		dbErr := NewError(ErrDatabase, "LoadFixtureFile", "failed to load fixture").
-			WithContext("fixture", fixturePath).
-			WithContext("error", err.Error())
-		t.Fatalf("%+v", dbErr)
+	for _, fixtureName := range fixtures {
+		fixturePath := filepath.Join(fixturesDir, fixtureName+".yml")
+		if err := db.LoadFixture(t.Context(), fixturePath); err != nil {
+			dbErr := NewError(ErrDatabase, op, "failed to load fixture").
+				WithContext("fixture", fixturePath).
+				WithContext("error", err.Error())
+			t.Fatalf("%+v", dbErr)
+		}
 	}
 }
 
-// ExecuteDBChecks executes all database checks for a step
-func ExecuteDBChecks(t *testing.T, connStr string, step Step, ctxMap map[string]any) {
+// LoadCaseFixtures loads the fixtures of a test case into db in order. Inline
+// rows are rendered with the context; consecutive inline entries are loaded
+// together like the tables of one fixture file
+func LoadCaseFixtures(
+	t *testing.T,
+	db Database,
+	fixturesDir string,
+	fixtures []Fixture,
+	ctx map[string]any,
+	opts RenderOptions,
+) {
 	t.Helper()
-	const op = "ExecuteDBChecks"
+	const op = "LoadCaseFixtures"
 
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		dbErr := NewError(ErrDatabase, op, "failed to open database connection").
-			WithContext("step", step.Name).
-			WithContext("error", err.Error())
+	if len(fixtures) > 0 && db == nil {
+		names := make([]string, len(fixtures))
+		for i, f := range fixtures {
+			names[i] = f.String()
+		}
+		dbErr := NewError(ErrDatabase, op, "fixtures require a database").
+			WithContext("fixtures", strings.Join(names, ", "))
 		t.Fatalf("%+v", dbErr)
 	}
-	defer db.Close()
 
-	for _, check := range step.DBChecks {
-		check = renderDBCheck(check, ctxMap)
-		ExecuteDBCheck(t, db, check)
+	var inline []Fixture
+	flush := func() {
+		if len(inline) == 0 {
+			return
+		}
+
+		path, err := writeInlineFixture(t.TempDir(), inline, ctx, opts)
+		if err != nil {
+			dbErr := NewError(ErrInvalidInput, op, "failed to render inline fixture").
+				WithContext("error", err.Error())
+			t.Fatalf("%+v", dbErr)
+		}
+		inline = nil
+
+		if err := db.LoadFixture(t.Context(), path); err != nil {
+			dbErr := NewError(ErrDatabase, op, "failed to load inline fixture").
+				WithContext("error", err.Error())
+			t.Fatalf("%+v", dbErr)
+		}
+	}
+
+	for _, fixture := range fixtures {
+		if fixture.File == "" {
+			inline = append(inline, fixture)
+
+			continue
+		}
+
+		flush()
+		fixturePath := filepath.Join(fixturesDir, fixture.File+".yml")
+		if err := db.LoadFixture(t.Context(), fixturePath); err != nil {
+			dbErr := NewError(ErrDatabase, op, "failed to load fixture").
+				WithContext("fixture", fixturePath).
+				WithContext("error", err.Error())
+			t.Fatalf("%+v", dbErr)
+		}
 	}
+	flush()
 }
 
-// ExecuteDBCheck executes a single database check
-func ExecuteDBCheck(t *testing.T, db *sql.DB, check DBCheck) {
+// ExecuteDBCheck executes a single database check. With eventually set, the
+// query is repeated until the rows match or the timeout passes. It returns the
+// rows of the matching query and whether the check passed
+func ExecuteDBCheck(t *testing.T, db Database, check DBCheck) ([]map[string]any, bool) {
 	t.Helper()
 	const op = "ExecuteDBCheck"
 
-	rows, err := db.QueryContext(t.Context(), check.Query)
+	expectedJSON, err := expectedDBResult(check.Result)
 	if err != nil {
-		dbErr := NewError(ErrDatabase, op, "failed to execute database query").
-			WithContext("query", check.Query).
+		dbErr := NewError(ErrInternal, op, "failed to marshal expected results").
 			WithContext("error", err.Error())
 		t.Fatalf("%+v", dbErr)
 	}
-	defer rows.Close()
 
-	cols, err := rows.Columns()
+	timeout, interval, err := ParseEventually(check.Eventually)
 	if err != nil {
-		dbErr := NewError(ErrDatabase, op, "failed to get column information").
+		dbErr := NewError(ErrInvalidInput, op, "invalid eventually").
+			WithContext("query", check.Query).
 			WithContext("error", err.Error())
 		t.Fatalf("%+v", dbErr)
 	}
+	deadline := time.Now().Add(timeout)
 
-	results := make([]map[string]any, 0)
+	for attempt := 1; ; attempt++ {
+		results, err := db.Query(t.Context(), check.Query, check.Args...)
+		if err != nil {
+			dbErr := NewError(ErrDatabase, op, "failed to execute database query").
+				WithContext("query", check.Query).
+				WithContext("args", fmt.Sprintf("%v", check.Args)).
+				WithContext("error", err.Error())
+			t.Fatalf("%+v", dbErr)
+		}
 
-	for rows.Next() {
-		row := make([]any, len(cols))
-		ptrs := make([]any, len(cols))
-		for i := range ptrs {
-			ptrs[i] = &row[i]
+		actual, err := json.Marshal(results)
+		if err != nil {
+			dbErr := NewError(ErrInternal, op, "failed to marshal query results").
+				WithContext("error", err.Error())
+			t.Fatalf("%+v", dbErr)
 		}
-		if err := rows.Scan(ptrs...); err != nil {
-			dbErr := NewError(ErrDatabase, op, "failed to scan row data").
+
+		diffs, err := dbCheckDiffs(actual, expectedJSON, check)
+		if err != nil {
+			dbErr := NewError(ErrInvalidInput, op, "invalid database check").
+				WithContext("query", check.Query).
 				WithContext("error", err.Error())
 			t.Fatalf("%+v", dbErr)
 		}
+		if len(diffs) == 0 {
+			rows, err := decodeRows(actual)
+			if err != nil {
+				dbErr := NewError(ErrInternal, op, "failed to decode query results").
+					WithContext("error", err.Error())
+				t.Fatalf("%+v", dbErr)
+			}
+
+			return rows, true
+		}
+
+		if check.Eventually == nil {
+			for _, diff := range diffs {
+				t.Errorf("database check failed: %s", diff)
+			}
+
+			return nil, false
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			dbErr := NewError(ErrDatabase, op, "database check did not match before timeout").
+				WithContext("query", check.Query).
+				WithContext("timeout", timeout.String()).
+				WithContext("attempts", attempt).
+				WithContext("actual", string(actual)).
+				WithContext("differences", strings.Join(diffs, "; "))
+			t.Errorf("%+v", dbErr)
 
-		m := make(map[string]any)
-		for i, col := range cols {
-			m[col] = row[i]
+			return nil, false
 		}
 
-		results = append(results, m)
+		select {
+		case <-t.Context().Done():
+			return nil, false
+		case <-time.After(interval):
+		}
 	}
+}
 
-	actual, err := json.Marshal(results)
+// expectedDBResult returns the expected rows of a dbCheck as JSON
+func expectedDBResult(result any) (string, error) {
+	if s, ok := result.(string); ok {
+		return s, nil
+	}
+
+	buf, err := json.Marshal(result)
 	if err != nil {
-		dbErr := NewError(ErrInternal, op, "failed to marshal query results").
-			WithContext("error", err.Error())
-		t.Fatalf("%+v", dbErr)
+		return "", err
 	}
 
-	var expectedJSON string
-	switch v := check.Result.(type) {
-	case string:
-		expectedJSON = v
-	default:
-		buf, err := json.Marshal(v)
-		if err != nil {
-			dbErr := NewError(ErrInternal, op, "failed to marshal expected results").
-				WithContext("error", err.Error())
-			t.Fatalf("%+v", dbErr)
+	return string(buf), nil
+}
+
+// diffCollector receives jsonassert failures instead of failing the test
+type diffCollector struct {
+	diffs []string
+}
+
+func (c *diffCollector) Errorf(msg string, args ...any) {
+	c.diffs = append(c.diffs, fmt.Sprintf(msg, args...))
+}
+
+// compareJSON returns the jsonassert differences between actual and expected
+func compareJSON(actual, expected string) []string {
+	collector := &diffCollector{}
+	jsonassert.New(collector).Assert(actual, expected)
+
+	return collector.diffs
+}
+
+// ParseEventually returns the polling timeout and interval of a dbCheck.
+// The interval defaults to 100ms; a nil spec means a single attempt
+func ParseEventually(spec *EventuallySpec) (timeout, interval time.Duration, err error) {
+	if spec == nil {
+		return 0, 0, nil
+	}
+
+	if timeout, err = time.ParseDuration(spec.Timeout); err != nil {
+		return 0, 0, fmt.Errorf("invalid timeout: %w", err)
+	}
+	if timeout <= 0 {
+		return 0, 0, fmt.Errorf("timeout must be positive")
+	}
+
+	interval = 100 * time.Millisecond
+	if spec.Interval != "" {
+		if interval, err = time.ParseDuration(spec.Interval); err != nil {
+			return 0, 0, fmt.Errorf("invalid interval: %w", err)
 		}
+		if interval <= 0 {
+			return 0, 0, fmt.Errorf("interval must be positive")
+		}
+	}
 
-		expectedJSON = string(buf)
+	return timeout, interval, nil
+}
+
+// decodeColumnValue converts raw bytes returned by the driver (MySQL returns
+// strings, decimals, dates and JSON as []byte) into JSON-friendly values.
+// Binary columns are left as bytes and encode as base64
+func decodeColumnValue(val any, dbTypeName string) any {
+	b, ok := val.([]byte)
+	if !ok {
+		return val
+	}
+
+	switch strings.ToUpper(dbTypeName) {
+	case "JSON", "JSONB":
+		var decoded any
+		if err := json.Unmarshal(b, &decoded); err == nil {
+			return decoded
+		}
+	case "DECIMAL", "NUMERIC":
+		return json.Number(b)
+	case "BINARY", "VARBINARY", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BIT", "BYTEA":
+		return b
 	}
 
-	ja := jsonassert.New(t)
-	ja.Assert(string(actual), expectedJSON)
+	return string(b)
 }
@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rom8726/pgfixtures"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDriverName(t *testing.T) {
+	if got := DriverName(pgfixtures.MySQL); got != "mysql" {
+		t.Errorf("Expected mysql, got %s", got)
+	}
+	if got := DriverName(pgfixtures.PostgreSQL); got != "postgres" {
+		t.Errorf("Expected postgres, got %s", got)
+	}
+}
+
+func TestDecodeColumnValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		val      any
+		dbType   string
+		expected any
+	}{
+		{name: "varchar", val: []byte("Alice"), dbType: "VARCHAR", expected: "Alice"},
+		{name: "datetime", val: []byte("2024-01-02 10:00:00"), dbType: "DATETIME", expected: "2024-01-02 10:00:00"},
+		{name: "decimal", val: []byte("10.50"), dbType: "DECIMAL", expected: json.Number("10.50")},
+		{name: "json", val: []byte(`{"a":[1,2]}`), dbType: "JSON", expected: map[string]any{"a": []any{float64(1), float64(2)}}},
+		{name: "blob", val: []byte{0x01, 0x02}, dbType: "BLOB", expected: []byte{0x01, 0x02}},
+		{name: "integer", val: int64(7), dbType: "INT", expected: int64(7)},
+		{name: "null", val: nil, dbType: "VARCHAR", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decodeColumnValue(tt.val, tt.dbType)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %#v, got %#v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// delayedDB returns no rows until it has been queried ready times
+type delayedDB struct {
+	recordingDB
+	ready   int
+	queries int
+}
+
+func (d *delayedDB) Query(ctx context.Context, query string, args ...any) ([]map[string]any, error) {
+	d.queries++
+	if d.queries < d.ready {
+		return []map[string]any{}, nil
+	}
+
+	return d.rows, nil
+}
+
+func TestExecuteDBCheck_Eventually(t *testing.T) {
+	db := &delayedDB{ready: 3}
+	db.rows = []map[string]any{{"status": "done"}}
+
+	ExecuteDBCheck(t, db, DBCheck{
+		Query:      "SELECT status FROM jobs",
+		Result:     []any{map[string]any{"status": "done"}},
+		Eventually: &EventuallySpec{Timeout: "2s", Interval: "10ms"},
+	})
+
+	if db.queries != 3 {
+		t.Errorf("Expected 3 queries, got %d", db.queries)
+	}
+}
+
+func TestParseEventually(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     *EventuallySpec
+		timeout  time.Duration
+		interval time.Duration
+		wantErr  bool
+	}{
+		{name: "nil", spec: nil},
+		{name: "default interval", spec: &EventuallySpec{Timeout: "5s"}, timeout: 5 * time.Second, interval: 100 * time.Millisecond},
+		{name: "custom interval", spec: &EventuallySpec{Timeout: "1s", Interval: "50ms"}, timeout: time.Second, interval: 50 * time.Millisecond},
+		{name: "missing timeout", spec: &EventuallySpec{}, wantErr: true},
+		{name: "invalid interval", spec: &EventuallySpec{Timeout: "1s", Interval: "soon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout, interval, err := ParseEventually(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if timeout != tt.timeout || interval != tt.interval {
+				t.Errorf("Expected %s/%s, got %s/%s", tt.timeout, tt.interval, timeout, interval)
+			}
+		})
+	}
+}
+
+func TestLoadCaseFixtures_Inline(t *testing.T) {
+	fixturesDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(fixturesDir, "plans.yml"), []byte("plans:\n  - id: 1\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	var fixtures []Fixture
+	data := `
+- plans
+- table: users
+  rows:
+    - {id: "{{userId}}", email: "{{email}}"}
+- table: orders
+  rows:
+    - {id: 7, user_id: "{{userId}}"}
+- table: users
+  rows:
+    - {id: 2, email: "{{ email | upper }}"}
+`
+	if err := yaml.Unmarshal([]byte(data), &fixtures); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	db := &recordingDB{}
+	ctx := map[string]any{"userId": 1, "email": "a@example.com"}
+	LoadCaseFixtures(t, db, fixturesDir, fixtures, ctx, DefaultRenderOptions())
+
+	expectedExecs := []string{
+		`DELETE FROM "plans"`,
+		`INSERT INTO "plans" ("id") VALUES (?)`,
+		`DELETE FROM "orders"`,
+		`DELETE FROM "users"`,
+		`INSERT INTO "users" ("email", "id") VALUES (?, ?)`,
+		`INSERT INTO "users" ("email", "id") VALUES (?, ?)`,
+		`INSERT INTO "orders" ("id", "user_id") VALUES (?, ?)`,
+	}
+	if !reflect.DeepEqual(db.execs, expectedExecs) {
+		t.Errorf("Expected statements %q, got %q", expectedExecs, db.execs)
+	}
+
+	expectedArgs := [][]any{{"a@example.com", 1}, {"A@EXAMPLE.COM", 2}, {7, 1}}
+	if !reflect.DeepEqual(db.args[4:], expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, db.args[4:])
+	}
+}
+
+func TestFixture_UnmarshalYAML(t *testing.T) {
+	var fixtures []Fixture
+	if err := yaml.Unmarshal([]byte("- rows: [{id: 1}]"), &fixtures); err == nil {
+		t.Error("Expected error for inline fixture without table")
+	}
+}
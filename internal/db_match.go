@@ -0,0 +1,244 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Match modes for dbChecks
+const (
+	// DBMatchExact compares every row and column in order (default)
+	DBMatchExact = "exact"
+	// DBMatchUnordered compares every row and column in any order
+	DBMatchUnordered = "unordered"
+	// DBMatchSubset requires each expected row to match a distinct actual row,
+	// comparing only the listed columns
+	DBMatchSubset = "subset"
+)
+
+// dbCheckDiffs compares the rows returned by a dbCheck query with its
+// expectations and returns a description of every mismatch. Without result,
+// only rowCount is checked when the dbCheck counts or extracts rows
+func dbCheckDiffs(actual []byte, expectedJSON string, check DBCheck) ([]string, error) {
+	var diffs []string
+
+	actualRows, err := decodeRows(actual)
+	if err != nil {
+		return nil, err
+	}
+
+	if check.RowCount != nil && len(actualRows) != *check.RowCount {
+		diffs = append(diffs, fmt.Sprintf("expected %d rows, got %d", *check.RowCount, len(actualRows)))
+	}
+	if check.Result == nil && (check.RowCount != nil || len(check.Extract) > 0) {
+		return diffs, nil
+	}
+
+	var expected any
+	if err := json.Unmarshal([]byte(expectedJSON), &expected); err != nil && check.Match != "" {
+		return nil, fmt.Errorf("invalid expected result: %w", err)
+	}
+
+	switch check.Match {
+	case "", DBMatchExact:
+		if !hasColumnOperators(expected) {
+			return append(diffs, compareJSON(string(actual), expectedJSON)...), nil
+		}
+	case DBMatchUnordered, DBMatchSubset:
+	default:
+		return nil, fmt.Errorf("unknown match mode: %s", check.Match)
+	}
+
+	expectedRows, err := toRows(expected)
+	if err != nil {
+		return nil, err
+	}
+
+	switch check.Match {
+	case DBMatchUnordered:
+		if len(actualRows) != len(expectedRows) {
+			diffs = append(diffs, fmt.Sprintf("expected %d rows, got %d", len(expectedRows), len(actualRows)))
+		}
+		diffs = append(diffs, matchRows(actualRows, expectedRows, false)...)
+	case DBMatchSubset:
+		diffs = append(diffs, matchRows(actualRows, expectedRows, true)...)
+	default:
+		if len(actualRows) != len(expectedRows) {
+			diffs = append(diffs, fmt.Sprintf("expected %d rows, got %d", len(expectedRows), len(actualRows)))
+		}
+		for i := 0; i < len(actualRows) && i < len(expectedRows); i++ {
+			for _, diff := range rowDiffs(actualRows[i], expectedRows[i], false) {
+				diffs = append(diffs, fmt.Sprintf("row %d: %s", i, diff))
+			}
+		}
+	}
+
+	return diffs, nil
+}
+
+// decodeRows decodes marshalled query results, normalising column values to
+// the JSON types used in expectations and the context
+func decodeRows(actual []byte) ([]map[string]any, error) {
+	var rows []map[string]any
+	if err := json.Unmarshal(actual, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode query results: %w", err)
+	}
+
+	return rows, nil
+}
+
+// toRows converts a decoded expected result to a list of rows
+func toRows(expected any) ([]map[string]any, error) {
+	list, ok := expected.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected result must be a list of rows")
+	}
+
+	rows := make([]map[string]any, len(list))
+	for i, item := range list {
+		row, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected row %d must be an object", i)
+		}
+		rows[i] = row
+	}
+
+	return rows, nil
+}
+
+// columnOperator returns the operator and value of a column expectation
+// written as {operator: gt, value: 10}
+func columnOperator(v any) (string, any, bool) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return "", nil, false
+	}
+
+	op, ok := m["operator"].(string)
+	if !ok {
+		return "", nil, false
+	}
+	for key := range m {
+		if key != "operator" && key != "value" {
+			return "", nil, false
+		}
+	}
+
+	return op, m["value"], true
+}
+
+// hasColumnOperators reports whether any expected column uses an operator
+func hasColumnOperators(expected any) bool {
+	rows, err := toRows(expected)
+	if err != nil {
+		return false
+	}
+
+	for _, row := range rows {
+		for _, v := range row {
+			if _, _, ok := columnOperator(v); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// rowDiffs compares one row. With partial set, columns that are not
+// expected are ignored
+func rowDiffs(actual, expected map[string]any, partial bool) []string {
+	cols := make([]string, 0, len(expected))
+	for col := range expected {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	var diffs []string
+	for _, col := range cols {
+		got, present := actual[col]
+
+		if op, want, ok := columnOperator(expected[col]); ok {
+			pass, err := evaluateAssertion(got, op, want)
+			if err != nil {
+				diffs = append(diffs, fmt.Sprintf("column %s: %v", col, err))
+			} else if !pass {
+				diffs = append(diffs, fmt.Sprintf("column %s: expected %s %v, got %v", col, op, want, got))
+			}
+
+			continue
+		}
+
+		if !present {
+			diffs = append(diffs, fmt.Sprintf("column %s is missing", col))
+		} else if !reflect.DeepEqual(got, expected[col]) {
+			diffs = append(diffs, fmt.Sprintf("column %s: expected %v, got %v", col, expected[col], got))
+		}
+	}
+
+	if !partial {
+		var extra []string
+		for col := range actual {
+			if _, ok := expected[col]; !ok {
+				extra = append(extra, col)
+			}
+		}
+		sort.Strings(extra)
+		for _, col := range extra {
+			diffs = append(diffs, fmt.Sprintf("unexpected column %s", col))
+		}
+	}
+
+	return diffs
+}
+
+// matchRows pairs every expected row with a distinct actual row regardless
+// of order and describes the expected rows left without a match
+func matchRows(actual, expected []map[string]any, partial bool) []string {
+	// candidates[i] lists the actual rows that satisfy expected row i
+	candidates := make([][]int, len(expected))
+	for i, want := range expected {
+		for j, got := range actual {
+			if len(rowDiffs(got, want, partial)) == 0 {
+				candidates[i] = append(candidates[i], j)
+			}
+		}
+	}
+
+	owner := make([]int, len(actual))
+	for j := range owner {
+		owner[j] = -1
+	}
+
+	// assign finds an actual row for expected row i, moving earlier
+	// assignments to other candidates when needed
+	var assign func(i int, seen []bool) bool
+	assign = func(i int, seen []bool) bool {
+		for _, j := range candidates[i] {
+			if seen[j] {
+				continue
+			}
+			seen[j] = true
+			if owner[j] < 0 || assign(owner[j], seen) {
+				owner[j] = i
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var diffs []string
+	for i, want := range expected {
+		if assign(i, make([]bool, len(actual))) {
+			continue
+		}
+
+		b, _ := json.Marshal(want)
+		diffs = append(diffs, fmt.Sprintf("no row matches expected row %d: %s", i, b))
+	}
+
+	return diffs
+}
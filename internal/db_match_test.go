@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDBCheckDiffs(t *testing.T) {
+	actual := `[{"id":1,"name":"Alice","created_at":"2024-01-02"},{"id":2,"name":"Bob","created_at":"2024-01-03"}]`
+	two := 2
+	three := 3
+
+	tests := []struct {
+		name     string
+		check    DBCheck
+		expected string
+		wantDiff string
+	}{
+		{
+			name:     "exact",
+			check:    DBCheck{Result: "x"},
+			expected: `[{"id":1,"name":"Alice","created_at":"2024-01-02"},{"id":2,"name":"Bob","created_at":"2024-01-03"}]`,
+		},
+		{
+			name:     "exact order mismatch",
+			check:    DBCheck{Result: "x"},
+			expected: `[{"id":2,"name":"Bob","created_at":"2024-01-03"},{"id":1,"name":"Alice","created_at":"2024-01-02"}]`,
+			wantDiff: "expected",
+		},
+		{
+			name:     "exact with operators",
+			check:    DBCheck{Result: "x"},
+			expected: `[{"id":1,"name":"Alice","created_at":{"operator":"isNotEmpty"}},{"id":{"operator":"gt","value":1},"name":"Bob","created_at":{"operator":"isNotEmpty"}}]`,
+		},
+		{
+			name:     "unordered",
+			check:    DBCheck{Result: "x", Match: DBMatchUnordered},
+			expected: `[{"id":2,"name":"Bob","created_at":"2024-01-03"},{"id":1,"name":"Alice","created_at":"2024-01-02"}]`,
+		},
+		{
+			name:     "unordered requires every column",
+			check:    DBCheck{Result: "x", Match: DBMatchUnordered},
+			expected: `[{"id":2,"name":"Bob"},{"id":1,"name":"Alice"}]`,
+			wantDiff: "no row matches",
+		},
+		{
+			name:     "unordered with operator rows",
+			check:    DBCheck{Result: "x", Match: DBMatchUnordered},
+			expected: `[{"id":{"operator":"gte","value":1},"name":{"operator":"in","value":["Alice","Bob"]},"created_at":{"operator":"isNotEmpty"}},{"id":1,"name":"Alice","created_at":"2024-01-02"}]`,
+		},
+		{
+			name:     "subset",
+			check:    DBCheck{Result: "x", Match: DBMatchSubset},
+			expected: `[{"name":"Bob"}]`,
+		},
+		{
+			name:     "subset missing row",
+			check:    DBCheck{Result: "x", Match: DBMatchSubset},
+			expected: `[{"name":"Carol"}]`,
+			wantDiff: "no row matches expected row 0",
+		},
+		{
+			name:     "subset rows must be distinct",
+			check:    DBCheck{Result: "x", Match: DBMatchSubset},
+			expected: `[{"name":"Bob"},{"name":"Bob"}]`,
+			wantDiff: "no row matches expected row 1",
+		},
+		{
+			name:  "row count only",
+			check: DBCheck{RowCount: &two},
+		},
+		{
+			name:     "row count mismatch",
+			check:    DBCheck{RowCount: &three},
+			wantDiff: "expected 3 rows, got 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := tt.expected
+			if expected == "" {
+				expected = "null"
+			}
+
+			diffs, err := dbCheckDiffs([]byte(actual), expected, tt.check)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			if tt.wantDiff == "" {
+				if len(diffs) != 0 {
+					t.Errorf("Expected no differences, got %v", diffs)
+				}
+				return
+			}
+			if !strings.Contains(strings.Join(diffs, "\n"), tt.wantDiff) {
+				t.Errorf("Expected a difference containing %q, got %v", tt.wantDiff, diffs)
+			}
+		})
+	}
+}
+
+func TestDBCheckDiffs_UnknownMatch(t *testing.T) {
+	_, err := dbCheckDiffs([]byte(`[]`), `[]`, DBCheck{Result: "x", Match: "fuzzy"})
+	if err == nil || !strings.Contains(err.Error(), "unknown match mode") {
+		t.Errorf("Expected unknown match mode error, got %v", err)
+	}
+}
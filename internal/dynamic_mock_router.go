@@ -3,40 +3,230 @@ package internal
 import (
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/julienschmidt/httprouter"
 )
 
+// DynamicMockRouter serves the routes of a mock server. The route table and
+// the call log are replaced together, atomically, so requests arriving after
+// a reset never reach the handlers of the previous case
 type DynamicMockRouter struct {
-	name   string
-	router *httprouter.Router
-	spy    *SpyStore
+	name  string
+	state atomic.Pointer[mockState]
 }
 
-func NewDynamicMockRouter(name string) *DynamicMockRouter {
-	return &DynamicMockRouter{
-		name:   name,
-		router: httprouter.New(),
-		spy:    &SpyStore{Calls: new([]MockCall)},
+// mockState is a route table, the call log its handlers record into and the
+// session holding sequence positions and named states
+type mockState struct {
+	router  *httprouter.Router
+	routes  []*mockRouteEntry
+	spy     *SpyStore
+	session *mockSession
+}
+
+// mockRouteEntry is a route and the number of times it answered
+type mockRouteEntry struct {
+	MockRoute
+	served int // guarded by mockSession.mu
+}
+
+// mockSession holds the named states set by routes and the context of
+// response templates. Its mutex also guards route selection, so sequences
+// and state transitions are atomic
+type mockSession struct {
+	mu    sync.Mutex
+	state map[string]string
+	vars  map[string]any
+	opts  RenderOptions
+}
+
+func newMockSession() *mockSession {
+	return &mockSession{state: make(map[string]string), opts: DefaultRenderOptions()}
+}
+
+// newRouteEntries wraps routes with fresh sequence positions
+func newRouteEntries(routes []MockRoute) []*mockRouteEntry {
+	entries := make([]*mockRouteEntry, len(routes))
+	for i, route := range routes {
+		entries[i] = &mockRouteEntry{MockRoute: route}
 	}
+
+	return entries
+}
+
+// next returns the response for the current call and advances the sequence
+func (e *mockRouteEntry) next() MockResponse {
+	if len(e.Responses) == 0 {
+		return e.Response
+	}
+
+	i := e.served
+	e.served++
+	if i >= len(e.Responses) {
+		if e.Policy == MockPolicyRepeat {
+			i %= len(e.Responses)
+		} else {
+			i = len(e.Responses) - 1
+		}
+	}
+
+	return e.Responses[i]
+}
+
+func NewDynamicMockRouter(name string) *DynamicMockRouter {
+	d := &DynamicMockRouter{name: name}
+	d.state.Store(&mockState{
+		router:  httprouter.New(),
+		spy:     &SpyStore{},
+		session: newMockSession(),
+	})
+
+	return d
 }
 
 func (d *DynamicMockRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	d.router.ServeHTTP(w, r)
+	d.state.Load().router.ServeHTTP(w, r)
+}
+
+// AddRoute adds a route to the current route table
+func (d *DynamicMockRouter) AddRoute(route MockRoute) error {
+	cur := d.state.Load()
+
+	routes := append(append([]*mockRouteEntry(nil), cur.routes...), newRouteEntries([]MockRoute{route})...)
+	state, err := d.buildState(routes, cur.spy, cur.session)
+	if err != nil {
+		return err
+	}
+	d.state.Store(state)
+
+	return nil
 }
 
-func (d *DynamicMockRouter) AddRoute(route MockRoute) {
-	d.router.Handle(route.Method, route.Path, buildMockHandler(d.name, route, d.spy))
+// Reset replaces the route table and starts an empty call log, restarted
+// sequences and no named states
+func (d *DynamicMockRouter) Reset(routes []MockRoute) error {
+	state, err := d.buildState(newRouteEntries(routes), &SpyStore{}, newMockSession())
+	if err != nil {
+		return err
+	}
+	d.state.Store(state)
+
+	return nil
+}
+
+// Overlay adds routes on top of the current ones and returns a function
+// restoring the previous table. Overlay routes are tried before the current
+// routes of the same method and path and replace those with the same match.
+// Calls keep being recorded in the current call log, and current routes
+// keep their sequence positions and named states
+func (d *DynamicMockRouter) Overlay(routes []MockRoute) (func(), error) {
+	prev := d.state.Load()
+
+	merged := make([]*mockRouteEntry, 0, len(prev.routes)+len(routes))
+	merged = append(merged, newRouteEntries(routes)...)
+	for _, entry := range prev.routes {
+		if !containsRoute(routes, entry.MockRoute) {
+			merged = append(merged, entry)
+		}
+	}
+
+	state, err := d.buildState(merged, prev.spy, prev.session)
+	if err != nil {
+		return nil, err
+	}
+	d.state.Store(state)
+
+	return func() { d.state.Store(prev) }, nil
+}
+
+// SetRenderContext sets the scenario values and options response templates
+// are rendered with until the next reset. vars is copied
+func (d *DynamicMockRouter) SetRenderContext(vars map[string]any, opts RenderOptions) {
+	copied := make(map[string]any, len(vars))
+	maps.Copy(copied, vars)
+
+	session := d.state.Load().session
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	session.vars = copied
+	session.opts = opts
 }
 
 func (d *DynamicMockRouter) Spy() *SpyStore {
-	return d.spy
+	return d.state.Load().spy
 }
 
-func buildMockHandler(name string, route MockRoute, spy *SpyStore) httprouter.Handle {
-	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+// buildState registers routes on a new router, one handler per method and
+// path. httprouter panics on conflicting paths; the panic is returned as an
+// error
+func (d *DynamicMockRouter) buildState(routes []*mockRouteEntry, spy *SpyStore, session *mockSession) (state *mockState, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("mock %s: invalid routes: %v", d.name, r)
+		}
+	}()
+
+	type routeKey struct{ method, path string }
+
+	var order []routeKey
+	groups := make(map[routeKey][]*mockRouteEntry)
+	for _, entry := range routes {
+		switch entry.Policy {
+		case "", MockPolicyThenLast, MockPolicyRepeat:
+		default:
+			return nil, fmt.Errorf("mock %s: route %s %s: unknown policy %q (expected thenLast or repeat)",
+				d.name, entry.Method, entry.Path, entry.Policy)
+		}
+
+		key := routeKey{entry.Method, entry.Path}
+		for _, other := range groups[key] {
+			if sameRoute(other.MockRoute, entry.MockRoute) {
+				return nil, fmt.Errorf("mock %s: duplicate route %s %s", d.name, entry.Method, entry.Path)
+			}
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], entry)
+	}
+
+	router := httprouter.New()
+	for _, key := range order {
+		router.Handle(key.method, key.path, buildMockHandler(d.name, groups[key], spy, session))
+	}
+
+	return &mockState{router: router, routes: routes, spy: spy, session: session}, nil
+}
+
+// containsRoute reports whether routes has a route with the method, path and
+// match of route
+func containsRoute(routes []MockRoute, route MockRoute) bool {
+	for _, r := range routes {
+		if sameRoute(r, route) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sameRoute reports whether two routes have the same method, path and match
+func sameRoute(a, b MockRoute) bool {
+	return a.Method == b.Method && a.Path == b.Path && reflect.DeepEqual(a.Match, b.Match)
+}
+
+// buildMockHandler serves the routes sharing a method and path. The first
+// route whose match holds answers with its rendered response and applies its
+// state changes; a request no route matches gets 404
+func buildMockHandler(name string, routes []*mockRouteEntry, spy *SpyStore, session *mockSession) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 		fmt.Printf(">> mock %q called\n", name)
 
 		body, _ := io.ReadAll(r.Body)
@@ -53,25 +243,67 @@ func buildMockHandler(name string, route MockRoute, spy *SpyStore) httprouter.Ha
 			Body:    string(body),
 		})
 
-		for k, v := range route.Response.Headers {
-			w.Header().Set(k, v)
+		session.mu.Lock()
+		entry, err := selectMockRoute(routes, &mockRequest{
+			headers: r.Header,
+			query:   r.URL.Query(),
+			body:    body,
+			state:   session.state,
+		})
+		var resp MockResponse
+		if entry != nil {
+			resp = entry.next()
+			for k, v := range entry.SetState {
+				session.state[k] = v
+			}
 		}
+		vars, opts := session.vars, session.opts
+		session.mu.Unlock()
 
-		if route.Response.JSON != "" {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(route.Response.Status)
-			_, _ = fmt.Fprint(w, route.Response.JSON)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("mock %s: %v", name, err), http.StatusInternalServerError)
 
 			return
 		}
-
-		if route.Response.Body != "" {
-			w.WriteHeader(route.Response.Status)
-			_, _ = fmt.Fprint(w, route.Response.Body)
+		if entry == nil {
+			http.Error(w, fmt.Sprintf("mock %s: no route matched %s %s", name, r.Method, r.URL.Path), http.StatusNotFound)
 
 			return
 		}
 
-		w.WriteHeader(route.Response.Status)
+		if hasPlaceholders(resp) {
+			resp, err = renderMockResponse(resp, mockTemplateContext(vars, r, params, body), opts)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("mock %s: failed to render response: %v", name, err), http.StatusInternalServerError)
+
+				return
+			}
+		}
+
+		writeMockResponse(w, resp)
+	}
+}
+
+// writeMockResponse writes a configured response
+func writeMockResponse(w http.ResponseWriter, resp MockResponse) {
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
+	}
+
+	if resp.JSON != "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.Status)
+		_, _ = fmt.Fprint(w, resp.JSON)
+
+		return
 	}
+
+	if resp.Body != "" {
+		w.WriteHeader(resp.Status)
+		_, _ = fmt.Fprint(w, resp.Body)
+
+		return
+	}
+
+	w.WriteHeader(resp.Status)
 }
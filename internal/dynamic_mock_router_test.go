@@ -0,0 +1,426 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func serveMock(router *DynamicMockRouter, method, path string) int {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+
+	return rec.Code
+}
+
+func serveMockRequest(router *DynamicMockRouter, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestDynamicMockRouter_Reset(t *testing.T) {
+	router := NewDynamicMockRouter("notifier")
+	routes := []MockRoute{{Method: http.MethodPost, Path: "/send", Response: MockResponse{Status: 202}}}
+
+	if err := router.Reset(routes); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if code := serveMock(router, http.MethodPost, "/send"); code != 202 {
+		t.Errorf("Expected 202, got %d", code)
+	}
+
+	// The same route in the next case replaces the table instead of panicking
+	if err := router.Reset(routes); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if calls := router.Spy().Len(); calls != 0 {
+		t.Errorf("Expected an empty call log after reset, got %d calls", calls)
+	}
+
+	if err := router.Reset(nil); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if code := serveMock(router, http.MethodPost, "/send"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 after routes are removed, got %d", code)
+	}
+}
+
+func TestDynamicMockRouter_DuplicateRoutes(t *testing.T) {
+	router := NewDynamicMockRouter("notifier")
+	routes := []MockRoute{
+		{Method: http.MethodPost, Path: "/send", Response: MockResponse{Status: 202}},
+		{Method: http.MethodPost, Path: "/send", Response: MockResponse{Status: 500}},
+	}
+
+	if err := router.Reset(routes); err == nil {
+		t.Error("Expected error for duplicate routes")
+	}
+	if err := router.AddRoute(MockRoute{Method: http.MethodGet, Path: "/status"}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := router.AddRoute(MockRoute{Method: http.MethodGet, Path: "/status"}); err == nil {
+		t.Error("Expected error when adding a duplicate route")
+	}
+}
+
+func TestDynamicMockRouter_Match(t *testing.T) {
+	router := NewDynamicMockRouter("payments")
+	routes := []MockRoute{
+		// The fallback is tried last even when declared first
+		{Method: http.MethodPost, Path: "/payments", Response: MockResponse{Status: http.StatusPaymentRequired}},
+		{
+			Method:   http.MethodPost,
+			Path:     "/payments",
+			Match:    &MockMatch{Body: map[string]any{"card.number": "4111111111111111"}},
+			Response: MockResponse{Status: http.StatusOK},
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/payments",
+			Match: &MockMatch{
+				Headers: map[string]any{"X-Region": "eu"},
+				Query:   map[string]any{"mode": map[string]any{"operator": "matches", "value": "^(test|sandbox)$"}},
+			},
+			Response: MockResponse{Status: http.StatusAccepted},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/payments",
+			Match:    &MockMatch{Body: map[string]any{"amount": map[string]any{"operator": "gt", "value": 1000}}},
+			Response: MockResponse{Status: http.StatusForbidden},
+		},
+	}
+	if err := router.Reset(routes); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		headers  map[string]string
+		body     string
+		expected int
+	}{
+		{"body value", "/payments", nil, `{"card":{"number":"4111111111111111"},"amount":5000}`, http.StatusOK},
+		{"header and query regex", "/payments?mode=sandbox", map[string]string{"X-Region": "eu"}, `{}`, http.StatusAccepted},
+		{"query regex mismatch", "/payments?mode=live", map[string]string{"X-Region": "eu"}, `{}`, http.StatusPaymentRequired},
+		{"body operator", "/payments", nil, `{"card":{"number":"4000000000000002"},"amount":5000}`, http.StatusForbidden},
+		{"fallback", "/payments", nil, `{"card":{"number":"4000000000000002"},"amount":10}`, http.StatusPaymentRequired},
+		{"non-JSON body", "/payments", nil, `card=4111111111111111`, http.StatusPaymentRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			if rec := serveMockRequest(router, req); rec.Code != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, rec.Code)
+			}
+		})
+	}
+
+	// Without a fallback an unmatched request is a 404
+	if err := router.Reset(routes[1:2]); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{}`))
+	if rec := serveMockRequest(router, req); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when no route matches, got %d", rec.Code)
+	}
+}
+
+func TestDynamicMockRouter_Sequence(t *testing.T) {
+	responses := []MockResponse{{Status: 503}, {Status: 503}, {Status: 200}}
+
+	tests := []struct {
+		name     string
+		policy   string
+		expected []int
+	}{
+		{"then last by default", "", []int{503, 503, 200, 200, 200}},
+		{"then last", MockPolicyThenLast, []int{503, 503, 200, 200, 200}},
+		{"repeat", MockPolicyRepeat, []int{503, 503, 200, 503, 503}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewDynamicMockRouter("upstream")
+			route := MockRoute{Method: http.MethodGet, Path: "/health", Responses: responses, Policy: tt.policy}
+			if err := router.Reset([]MockRoute{route}); err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			for i, expected := range tt.expected {
+				if code := serveMock(router, http.MethodGet, "/health"); code != expected {
+					t.Errorf("Call %d: expected %d, got %d", i+1, expected, code)
+				}
+			}
+		})
+	}
+
+	router := NewDynamicMockRouter("upstream")
+	if err := router.Reset([]MockRoute{{Method: http.MethodGet, Path: "/health", Responses: responses, Policy: "cycle"}}); err == nil {
+		t.Error("Expected error for an unknown policy")
+	}
+}
+
+func TestDynamicMockRouter_State(t *testing.T) {
+	router := NewDynamicMockRouter("orders")
+	routes := []MockRoute{
+		{
+			Method:   http.MethodPost,
+			Path:     "/orders/1/cancel",
+			SetState: map[string]string{"order": "cancelled"},
+			Response: MockResponse{Status: http.StatusNoContent},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/orders/1",
+			Match:    &MockMatch{State: map[string]any{"order": "cancelled"}},
+			Response: MockResponse{Status: http.StatusGone},
+		},
+		{Method: http.MethodGet, Path: "/orders/1", Response: MockResponse{Status: http.StatusOK}},
+	}
+	if err := router.Reset(routes); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if code := serveMock(router, http.MethodGet, "/orders/1"); code != http.StatusOK {
+		t.Errorf("Expected 200 before cancel, got %d", code)
+	}
+
+	// A step overlay shares the state of the case
+	restore, err := router.Overlay([]MockRoute{{Method: http.MethodGet, Path: "/ping", Response: MockResponse{Status: 200}}})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	serveMock(router, http.MethodPost, "/orders/1/cancel")
+	restore()
+
+	if code := serveMock(router, http.MethodGet, "/orders/1"); code != http.StatusGone {
+		t.Errorf("Expected 410 after cancel, got %d", code)
+	}
+
+	if err := router.Reset(routes); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if code := serveMock(router, http.MethodGet, "/orders/1"); code != http.StatusOK {
+		t.Errorf("Expected state to be cleared by reset, got %d", code)
+	}
+}
+
+func TestDynamicMockRouter_Template(t *testing.T) {
+	tests := []struct {
+		name     string
+		response MockResponse
+		target   string
+		headers  map[string]string
+		body     string
+		expected string
+	}{
+		{
+			name:     "method",
+			response: MockResponse{Status: 200, Body: "{{request.method}}"},
+			target:   "/users/42",
+			expected: "POST",
+		},
+		{
+			name:     "path parameter",
+			response: MockResponse{Status: 200, Body: "user {{request.path.id}}"},
+			target:   "/users/42",
+			expected: "user 42",
+		},
+		{
+			name:     "query parameter",
+			response: MockResponse{Status: 200, Body: "page {{request.query.page}}"},
+			target:   "/users/42?page=3&page=4",
+			expected: "page 3",
+		},
+		{
+			name:     "header",
+			response: MockResponse{Status: 200, Body: "{{request.headers.X-Request-Id}}"},
+			target:   "/users/42",
+			headers:  map[string]string{"X-Request-Id": "req-1"},
+			expected: "req-1",
+		},
+		{
+			name:     "nested JSON body",
+			response: MockResponse{Status: 200, Body: "{{request.body.user.name}}"},
+			target:   "/users/42",
+			body:     `{"user": {"name": "Alice"}}`,
+			expected: "Alice",
+		},
+		{
+			name:     "typed JSON value",
+			response: MockResponse{Status: 200, JSON: `{"userId": "{{request.body.user_id}}", "tags": "{{request.body.tags}}"}`},
+			target:   "/users/42",
+			body:     `{"user_id": 7, "tags": ["a", "b"]}`,
+			expected: `{"userId": 7, "tags": ["a","b"]}`,
+		},
+		{
+			name:     "text body",
+			response: MockResponse{Status: 200, Body: "got {{request.body}}"},
+			target:   "/users/42",
+			body:     "plain text",
+			expected: "got plain text",
+		},
+		{
+			name:     "scenario variable",
+			response: MockResponse{Status: 200, Body: "{{tenant}}/{{request.path.id}}"},
+			target:   "/users/42",
+			expected: "acme/42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewDynamicMockRouter("users")
+			route := MockRoute{Method: http.MethodPost, Path: "/users/:id", Response: tt.response}
+			if err := router.Reset([]MockRoute{route}); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			router.SetRenderContext(map[string]any{"tenant": "acme"}, DefaultRenderOptions())
+
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := serveMockRequest(router, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if got := rec.Body.String(); got != tt.expected {
+				t.Errorf("Expected body %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDynamicMockRouter_TemplateHeadersAndFaker(t *testing.T) {
+	router := NewDynamicMockRouter("users")
+	if err := router.Reset([]MockRoute{{
+		Method: http.MethodGet,
+		Path:   "/users/:id",
+		Response: MockResponse{
+			Status:  http.StatusOK,
+			Headers: map[string]string{"X-Request-Id": "{{request.headers.X-Request-Id}}", "X-User": "{{request.path.id}}"},
+			JSON:    `{"token": "{{faker.uuid}}"}`,
+		},
+	}}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	rec := serveMockRequest(router, req)
+
+	if got := rec.Header().Get("X-Request-Id"); got != "req-1" {
+		t.Errorf("Expected X-Request-Id req-1, got %q", got)
+	}
+	if got := rec.Header().Get("X-User"); got != "42" {
+		t.Errorf("Expected X-User 42, got %q", got)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected JSON body, got %q: %v", rec.Body.String(), err)
+	}
+	if token, _ := body["token"].(string); len(token) != 36 {
+		t.Errorf("Expected a generated uuid, got %v", body["token"])
+	}
+}
+
+func TestDynamicMockRouter_TemplateStrict(t *testing.T) {
+	router := NewDynamicMockRouter("users")
+	if err := router.Reset([]MockRoute{{
+		Method:   http.MethodGet,
+		Path:     "/users/:id",
+		Response: MockResponse{Status: http.StatusOK, Body: "{{request.path.id}} {{request.query.missing}}"},
+	}}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	// Permissive rendering leaves the placeholder in place
+	expected := "42 {{request.query.missing}}"
+	if rec := serveMockRequest(router, httptest.NewRequest(http.MethodGet, "/users/42", nil)); rec.Body.String() != expected {
+		t.Errorf("Expected permissive body %q, got %q", expected, rec.Body.String())
+	}
+
+	router.SetRenderContext(nil, RenderOptions{Mode: RenderModeStrict})
+	rec := serveMockRequest(router, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 for an unresolved placeholder in strict mode, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "request.query.missing") {
+		t.Errorf("Expected the unresolved placeholder in the error, got %q", rec.Body.String())
+	}
+}
+
+func TestDynamicMockRouter_Overlay(t *testing.T) {
+	router := NewDynamicMockRouter("notifier")
+	if err := router.Reset([]MockRoute{{Method: http.MethodPost, Path: "/send", Response: MockResponse{Status: 202}}}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	restore, err := router.Overlay([]MockRoute{{Method: http.MethodPost, Path: "/send", Response: MockResponse{Status: 503}}})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if code := serveMock(router, http.MethodPost, "/send"); code != 503 {
+		t.Errorf("Expected step route to answer 503, got %d", code)
+	}
+
+	restore()
+	if code := serveMock(router, http.MethodPost, "/send"); code != 202 {
+		t.Errorf("Expected case route to answer 202 after the step, got %d", code)
+	}
+	if calls := router.Spy().Len(); calls != 2 {
+		t.Errorf("Expected step calls to stay in the case log, got %d calls", calls)
+	}
+}
+
+func TestDynamicMockRouter_ConcurrentCalls(t *testing.T) {
+	router := NewDynamicMockRouter("notifier")
+	if err := router.Reset([]MockRoute{
+		{Method: http.MethodPost, Path: "/send", Response: MockResponse{Status: http.StatusAccepted}},
+	}); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+
+	const workers = 20
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			serveMock(router, http.MethodPost, "/send")
+		}()
+		go func() {
+			defer wg.Done()
+			for _, call := range router.Spy().Calls() {
+				call.Headers["X-Mutated"] = "1"
+			}
+		}()
+	}
+	wg.Wait()
+
+	calls := router.Spy().Calls()
+	if len(calls) != workers {
+		t.Fatalf("Expected %d calls, got %d", workers, len(calls))
+	}
+	for _, call := range calls {
+		if _, ok := call.Headers["X-Mutated"]; ok {
+			t.Errorf("Expected snapshots to be independent of the store")
+		}
+	}
+}
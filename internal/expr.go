@@ -0,0 +1,666 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// legacyKeyRe matches placeholder contents that are plain context keys or
+// faker calls. They are looked up as-is before being parsed as expressions,
+// so keys like {{create-user.response.id}} keep working
+var legacyKeyRe = regexp.MustCompile(`^(?:` + placeholderKey + `)$`)
+
+// evalPlaceholder resolves the content of a {{ ... }} placeholder: a context
+// key, a faker call or an expression such as {{ page + 1 }} or
+// {{ name | upper }}. Missing values are reported as *UnresolvedPlaceholdersError
+func evalPlaceholder(content string, ctx map[string]any, opts RenderOptions) (any, error) {
+	legacy := legacyKeyRe.MatchString(content)
+	if legacy {
+		if val, ok := resolvePlaceholder(content, ctx, opts); ok {
+			return val, nil
+		}
+	}
+
+	val, err := evalExpression(content, ctx, opts, false)
+	if err != nil {
+		var unresolved *UnresolvedPlaceholdersError
+		if legacy && (errors.As(err, &unresolved) || errors.Is(err, errExprSyntax)) {
+			return nil, &UnresolvedPlaceholdersError{Keys: []string{content}}
+		}
+
+		return nil, err
+	}
+
+	return val, nil
+}
+
+// evalExpression parses and evaluates an expression. With bareWords
+// identifiers are string values rather than context lookups, as in
+// conditions that reference variables through placeholders: {{status}} == active
+func evalExpression(input string, ctx map[string]any, opts RenderOptions, bareWords bool) (any, error) {
+	node, err := parseExpression(input)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &exprEnv{ctx: ctx, opts: opts, bareWords: bareWords}
+
+	return node.eval(env)
+}
+
+// errExprSyntax wraps every expression parse error
+var errExprSyntax = errors.New("invalid expression")
+
+// exprEnv is the evaluation environment of an expression
+type exprEnv struct {
+	ctx       map[string]any
+	opts      RenderOptions
+	bareWords bool
+}
+
+// lookup resolves an identifier: a context key, a path into a context value
+// (user.tags[0]) or a faker generator without arguments
+func (e *exprEnv) lookup(name string) (any, error) {
+	if val, ok := resolvePlaceholder(name, e.ctx, e.opts); ok {
+		return val, nil
+	}
+
+	for i := len(name) - 1; i > 0; i-- {
+		if name[i] != '.' && name[i] != '[' {
+			continue
+		}
+
+		base, ok := e.ctx[name[:i]]
+		if !ok {
+			continue
+		}
+
+		if val, err := ParseJSONPath(strings.TrimPrefix(name[i:], "."), base); err == nil {
+			return val, nil
+		}
+	}
+
+	return nil, &UnresolvedPlaceholdersError{Keys: []string{name}}
+}
+
+// === Lexer ===
+
+type exprTokenKind int
+
+const (
+	exprEOF exprTokenKind = iota
+	exprNumber
+	exprString
+	exprIdent
+	exprOp
+	exprPlaceholder
+)
+
+type exprToken struct {
+	kind exprTokenKind
+	text string
+}
+
+var exprOperators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"<", ">", "+", "-", "*", "/", "%", "!", "(", ")", ",", "|",
+}
+
+func tokenizeExpression(input string) ([]exprToken, error) {
+	var tokens []exprToken
+
+	for i := 0; i < len(input); {
+		c := rune(input[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.HasPrefix(input[i:], "{{"):
+			end := strings.Index(input[i:], "}}")
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated placeholder", errExprSyntax)
+			}
+			tokens = append(tokens, exprToken{kind: exprPlaceholder, text: strings.TrimSpace(input[i+2 : i+end])})
+			i += end + 2
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(input) && (isDigit(input[i]) || input[i] == '.' && i+1 < len(input) && isDigit(input[i+1])) {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: exprNumber, text: input[start:i]})
+		case c == '"' || c == '\'':
+			text, n, err := lexString(input[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, exprToken{kind: exprString, text: text})
+			i += n
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			i = lexIdent(input, i)
+			tokens = append(tokens, exprToken{kind: exprIdent, text: input[start:i]})
+		default:
+			op := ""
+			for _, candidate := range exprOperators {
+				if strings.HasPrefix(input[i:], candidate) {
+					op = candidate
+
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("%w: unexpected character %q", errExprSyntax, c)
+			}
+			tokens = append(tokens, exprToken{kind: exprOp, text: op})
+			i += len(op)
+		}
+	}
+
+	return append(tokens, exprToken{kind: exprEOF}), nil
+}
+
+// lexIdent consumes an identifier path such as user.tags[0].name or
+// faker.email#alice and returns the index after it
+func lexIdent(input string, i int) int {
+	for i < len(input) {
+		c := input[i]
+		switch {
+		case c == '_' || c == '.' || c == '#' || isDigit(c) || unicode.IsLetter(rune(c)):
+			i++
+		case c == '[':
+			end := strings.IndexByte(input[i:], ']')
+			if end < 2 || strings.TrimLeft(input[i+1:i+end], "0123456789") != "" {
+				return i
+			}
+			i += end + 1
+		default:
+			return i
+		}
+	}
+
+	return i
+}
+
+// lexString reads a quoted string literal and returns its value and length
+func lexString(input string) (string, int, error) {
+	quote := input[0]
+
+	var sb strings.Builder
+	for i := 1; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case c == quote:
+			return sb.String(), i + 1, nil
+		case c == '\\' && i+1 < len(input):
+			i++
+			switch input[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(input[i])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return "", 0, fmt.Errorf("%w: unterminated string", errExprSyntax)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// === Parser ===
+//
+// pipe    := or ( "|" name [ "(" args ")" ] )*
+// or      := and ( ("||" | "or") and )*
+// and     := not ( ("&&" | "and") not )*
+// not     := ("!" | "not") not | compare
+// compare := add [ ("==" | "!=" | "<" | "<=" | ">" | ">=") add ]
+// add     := mul ( ("+" | "-") mul )*
+// mul     := unary ( ("*" | "/" | "%") unary )*
+// unary   := "-" unary | primary
+// primary := number | string | true | false | null | name [ "(" args ")" ] | "{{" placeholder "}}" | "(" pipe ")"
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+func parseExpression(input string) (exprNode, error) {
+	tokens, err := tokenizeExpression(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens}
+	node, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != exprEOF {
+		return nil, fmt.Errorf("%w: unexpected %q", errExprSyntax, tok.text)
+	}
+
+	return node, nil
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != exprEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+// accept consumes the next token if it is one of the given operators or keywords
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != exprOp && tok.kind != exprIdent {
+		return "", false
+	}
+
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+
+			return op, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *exprParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		return fmt.Errorf("%w: expected %q, got %q", errExprSyntax, op, p.peek().text)
+	}
+
+	return nil
+}
+
+func (p *exprParser) parsePipe() (exprNode, error) {
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("|"); !ok {
+			return node, nil
+		}
+
+		tok := p.next()
+		if tok.kind != exprIdent {
+			return nil, fmt.Errorf("%w: expected filter name after |", errExprSyntax)
+		}
+
+		args := []exprNode{node}
+		if _, ok := p.accept("("); ok {
+			rest, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, rest...)
+		}
+
+		node = &exprCall{name: tok.text, args: args}
+	}
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprLogical{op: "||", left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprLogical{op: "&&", left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseNot() (exprNode, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return &exprUnary{op: "!", operand: operand}, nil
+	}
+
+	return p.parseCompare()
+}
+
+func (p *exprParser) parseCompare() (exprNode, error) {
+	left, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+
+	if op, ok := p.accept("==", "!=", "<=", ">=", "<", ">"); ok {
+		right, err := p.parseAdd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &exprBinary{op: op, left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) parseAdd() (exprNode, error) {
+	left, err := p.parseMul()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+
+		right, err := p.parseMul()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseMul() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.accept("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &exprUnary{op: "-", operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case exprNumber:
+		if i, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return &exprLiteral{value: i}, nil
+		}
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid number %s", errExprSyntax, tok.text)
+		}
+
+		return &exprLiteral{value: f}, nil
+	case exprString:
+		return &exprLiteral{value: tok.text}, nil
+	case exprPlaceholder:
+		return &exprPlaceholderNode{content: tok.text}, nil
+	case exprIdent:
+		switch tok.text {
+		case "true":
+			return &exprLiteral{value: true}, nil
+		case "false":
+			return &exprLiteral{value: false}, nil
+		case "null", "nil":
+			return &exprLiteral{value: nil}, nil
+		}
+
+		if _, ok := p.accept("("); ok {
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+
+			return &exprCall{name: tok.text, args: args}, nil
+		}
+
+		return &exprIdentNode{name: tok.text}, nil
+	case exprOp:
+		if tok.text == "(" {
+			node, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+
+			return node, p.expect(")")
+		}
+	}
+
+	if tok.kind == exprEOF {
+		return nil, fmt.Errorf("%w: unexpected end of expression", errExprSyntax)
+	}
+
+	return nil, fmt.Errorf("%w: unexpected %q", errExprSyntax, tok.text)
+}
+
+// parseArgs parses a call argument list after the opening parenthesis
+func (p *exprParser) parseArgs() ([]exprNode, error) {
+	var args []exprNode
+	if _, ok := p.accept(")"); ok {
+		return args, nil
+	}
+
+	for {
+		arg, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		if _, ok := p.accept(")"); ok {
+			return args, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// === AST ===
+
+type exprNode interface {
+	eval(env *exprEnv) (any, error)
+}
+
+type exprLiteral struct {
+	value any
+}
+
+func (n *exprLiteral) eval(*exprEnv) (any, error) {
+	return n.value, nil
+}
+
+type exprIdentNode struct {
+	name string
+}
+
+func (n *exprIdentNode) eval(env *exprEnv) (any, error) {
+	if env.bareWords {
+		return n.name, nil
+	}
+
+	return env.lookup(n.name)
+}
+
+// exprPlaceholderNode is a {{ ... }} operand, evaluated to its typed value
+type exprPlaceholderNode struct {
+	content string
+}
+
+func (n *exprPlaceholderNode) eval(env *exprEnv) (any, error) {
+	return evalPlaceholder(n.content, env.ctx, env.opts)
+}
+
+type exprUnary struct {
+	op      string
+	operand exprNode
+}
+
+func (n *exprUnary) eval(env *exprEnv) (any, error) {
+	val, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "!" {
+		return !truthy(val), nil
+	}
+
+	return arithmetic("-", int64(0), val)
+}
+
+type exprLogical struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *exprLogical) eval(env *exprEnv) (any, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "&&" && !truthy(left) {
+		return false, nil
+	}
+	if n.op == "||" && truthy(left) {
+		return true, nil
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	return truthy(right), nil
+}
+
+type exprBinary struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *exprBinary) eval(env *exprEnv) (any, error) {
+	left, leftErr := n.left.eval(env)
+	right, rightErr := n.right.eval(env)
+	if err := mergeRenderErrors(leftErr, rightErr); err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return compareValues(n.op, left, right)
+	default:
+		return arithmetic(n.op, left, right)
+	}
+}
+
+type exprCall struct {
+	name string
+	args []exprNode
+}
+
+func (n *exprCall) eval(env *exprEnv) (any, error) {
+	args := make([]any, len(n.args))
+	errs := make([]error, len(n.args))
+	for i, arg := range n.args {
+		args[i], errs[i] = arg.eval(env)
+	}
+
+	// default() accepts a missing value as its subject
+	var unresolved *UnresolvedPlaceholdersError
+	if n.name == "default" && len(errs) > 0 && errors.As(errs[0], &unresolved) {
+		errs[0] = nil
+	}
+
+	if err := mergeRenderErrors(errs...); err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(n.name, fakerPrefix) {
+		strArgs := make([]string, len(args))
+		for i, arg := range args {
+			strArgs[i] = toString(arg)
+		}
+
+		return env.opts.faker().generate(env.opts.rand(), strings.TrimPrefix(n.name, fakerPrefix), strArgs...)
+	}
+
+	fn, ok := exprFuncs[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function: %s", n.name)
+	}
+
+	val, err := fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+
+	return val, nil
+}
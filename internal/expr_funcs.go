@@ -0,0 +1,490 @@
+package internal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// exprFunc is a template function. Used as a filter ({{ name | upper }}) it
+// receives the piped value as its first argument
+type exprFunc func(args []any) (any, error)
+
+// exprFuncs are the functions and filters available in expressions
+var exprFuncs = map[string]exprFunc{
+	"upper": stringFunc(strings.ToUpper),
+	"lower": stringFunc(strings.ToLower),
+	"trim":  stringFunc(strings.TrimSpace),
+	"base64": stringFunc(func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}),
+	"base64decode": func(args []any) (any, error) {
+		if err := wantArgs(args, 1); err != nil {
+			return nil, err
+		}
+		b, err := base64.StdEncoding.DecodeString(toString(args[0]))
+		if err != nil {
+			return nil, err
+		}
+
+		return string(b), nil
+	},
+	"urlencode": stringFunc(url.QueryEscape),
+	"string": func(args []any) (any, error) {
+		if err := wantArgs(args, 1); err != nil {
+			return nil, err
+		}
+
+		return toString(args[0]), nil
+	},
+	"int": func(args []any) (any, error) {
+		if err := wantArgs(args, 1); err != nil {
+			return nil, err
+		}
+		f, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("cannot convert %v to int", args[0])
+		}
+
+		return int64(f), nil
+	},
+	"float": func(args []any) (any, error) {
+		if err := wantArgs(args, 1); err != nil {
+			return nil, err
+		}
+		f, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("cannot convert %v to float", args[0])
+		}
+
+		return f, nil
+	},
+	"json": func(args []any) (any, error) {
+		if err := wantArgs(args, 1); err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return string(b), nil
+	},
+	"len": func(args []any) (any, error) {
+		if err := wantArgs(args, 1); err != nil {
+			return nil, err
+		}
+
+		switch v := args[0].(type) {
+		case nil:
+			return int64(0), nil
+		case string:
+			return int64(utf8.RuneCountInString(v)), nil
+		}
+
+		rv := reflect.ValueOf(args[0])
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return int64(rv.Len()), nil
+		default:
+			return int64(utf8.RuneCountInString(toString(args[0]))), nil
+		}
+	},
+	"default": func(args []any) (any, error) {
+		if err := wantArgs(args, 2); err != nil {
+			return nil, err
+		}
+		if args[0] == nil || args[0] == "" {
+			return args[1], nil
+		}
+
+		return args[0], nil
+	},
+	"join": func(args []any) (any, error) {
+		if err := wantArgs(args, 1, 2); err != nil {
+			return nil, err
+		}
+		sep := ","
+		if len(args) == 2 {
+			sep = toString(args[1])
+		}
+
+		items := toList(args[0])
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = toString(item)
+		}
+
+		return strings.Join(parts, sep), nil
+	},
+	"split": func(args []any) (any, error) {
+		if err := wantArgs(args, 1, 2); err != nil {
+			return nil, err
+		}
+		sep := ","
+		if len(args) == 2 {
+			sep = toString(args[1])
+		}
+
+		parts := strings.Split(toString(args[0]), sep)
+		out := make([]any, len(parts))
+		for i, part := range parts {
+			out[i] = part
+		}
+
+		return out, nil
+	},
+	"first": func(args []any) (any, error) {
+		if err := wantArgs(args, 1); err != nil {
+			return nil, err
+		}
+		if items := toList(args[0]); len(items) > 0 {
+			return items[0], nil
+		}
+
+		return nil, nil
+	},
+	"last": func(args []any) (any, error) {
+		if err := wantArgs(args, 1); err != nil {
+			return nil, err
+		}
+		if items := toList(args[0]); len(items) > 0 {
+			return items[len(items)-1], nil
+		}
+
+		return nil, nil
+	},
+	"replace": func(args []any) (any, error) {
+		if err := wantArgs(args, 3); err != nil {
+			return nil, err
+		}
+
+		return strings.ReplaceAll(toString(args[0]), toString(args[1]), toString(args[2])), nil
+	},
+	"contains": func(args []any) (any, error) {
+		if err := wantArgs(args, 2); err != nil {
+			return nil, err
+		}
+		if s, ok := args[0].(string); ok {
+			return strings.Contains(s, toString(args[1])), nil
+		}
+		for _, item := range toList(args[0]) {
+			if equal, _ := compareValues("==", item, args[1]); equal == true {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	},
+	"abs": numberFunc(math.Abs),
+	"round": func(args []any) (any, error) {
+		if err := wantArgs(args, 1, 2); err != nil {
+			return nil, err
+		}
+		f, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("cannot round %v", args[0])
+		}
+		if len(args) == 1 {
+			return int64(math.Round(f)), nil
+		}
+
+		places, _ := toNumber(args[1])
+		scale := math.Pow(10, places)
+
+		return math.Round(f*scale) / scale, nil
+	},
+	"min": func(args []any) (any, error) {
+		return extremum(args, func(a, b float64) bool { return a < b })
+	},
+	"max": func(args []any) (any, error) {
+		return extremum(args, func(a, b float64) bool { return a > b })
+	},
+}
+
+// stringFunc adapts a string transformation to a one-argument function
+func stringFunc(fn func(string) string) exprFunc {
+	return func(args []any) (any, error) {
+		if err := wantArgs(args, 1); err != nil {
+			return nil, err
+		}
+
+		return fn(toString(args[0])), nil
+	}
+}
+
+// numberFunc adapts a numeric transformation to a one-argument function
+func numberFunc(fn func(float64) float64) exprFunc {
+	return func(args []any) (any, error) {
+		if err := wantArgs(args, 1); err != nil {
+			return nil, err
+		}
+		if i, ok := toInt(args[0]); ok {
+			return int64(fn(float64(i))), nil
+		}
+		f, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("%v is not a number", args[0])
+		}
+
+		return fn(f), nil
+	}
+}
+
+// extremum returns the smallest or largest number of its arguments, or of a
+// single list argument
+func extremum(args []any, better func(a, b float64) bool) (any, error) {
+	if len(args) == 1 {
+		args = toList(args[0])
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("expected at least one value")
+	}
+
+	best := args[0]
+	bestNum, ok := toNumber(best)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", best)
+	}
+	for _, arg := range args[1:] {
+		n, ok := toNumber(arg)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a number", arg)
+		}
+		if better(n, bestNum) {
+			best, bestNum = arg, n
+		}
+	}
+
+	return best, nil
+}
+
+// wantArgs checks the number of arguments against the allowed counts
+func wantArgs(args []any, counts ...int) error {
+	for _, n := range counts {
+		if len(args) == n {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("expected %v arguments, got %d", counts, len(args))
+}
+
+// === Value helpers ===
+
+// truthy reports whether a value counts as true in conditions
+func truthy(v any) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		return isTruthy(val)
+	}
+
+	if f, ok := toNumber(v); ok {
+		return f != 0
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() > 0
+	default:
+		return true
+	}
+}
+
+// toString formats a value the way placeholders are rendered
+func toString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%+v", val)
+	}
+}
+
+// toList returns the elements of a slice or array value
+func toList(v any) []any {
+	if list, ok := v.([]any); ok {
+		return list
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil
+	}
+
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+
+	return out
+}
+
+// toInt returns v as an integer if it is an integer number or an integer string
+func toInt(v any) (int64, bool) {
+	switch val := v.(type) {
+	case int:
+		return int64(val), true
+	case int8:
+		return int64(val), true
+	case int16:
+		return int64(val), true
+	case int32:
+		return int64(val), true
+	case int64:
+		return val, true
+	case uint:
+		return int64(val), true
+	case uint8:
+		return int64(val), true
+	case uint16:
+		return int64(val), true
+	case uint32:
+		return int64(val), true
+	case uint64:
+		return int64(val), true
+	case json.Number:
+		i, err := val.Int64()
+		return i, err == nil
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// toNumber returns v as a float if it is a number or a numeric string
+func toNumber(v any) (float64, bool) {
+	if i, ok := toInt(v); ok {
+		return float64(i), true
+	}
+
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case json.Number:
+		f, err := val.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// arithmetic applies +, -, *, / or % to two values. Integers stay integers
+// unless a division has a remainder; + concatenates non-numeric values
+func arithmetic(op string, left, right any) (any, error) {
+	li, lInt := toInt(left)
+	ri, rInt := toInt(right)
+	lf, lNum := toNumber(left)
+	rf, rNum := toNumber(right)
+
+	if !lNum || !rNum {
+		if op == "+" {
+			return toString(left) + toString(right), nil
+		}
+
+		return nil, fmt.Errorf("operator %s requires numbers, got %v and %v", op, left, right)
+	}
+
+	if lInt && rInt {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "/", "%":
+			if ri == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			if op == "%" {
+				return li % ri, nil
+			}
+			if li%ri == 0 {
+				return li / ri, nil
+			}
+		}
+	}
+
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return lf / rf, nil
+	case "%":
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(lf, rf), nil
+	default:
+		return nil, fmt.Errorf("unknown operator: %s", op)
+	}
+}
+
+// compareValues compares numbers numerically, booleans and nil by identity
+// and everything else as strings
+func compareValues(op string, left, right any) (any, error) {
+	if lf, ok := toNumber(left); ok {
+		if rf, ok := toNumber(right); ok {
+			return compareNumbers(lf, op, rf)
+		}
+	}
+
+	_, lBool := left.(bool)
+	_, rBool := right.(bool)
+	if left == nil || right == nil || lBool || rBool {
+		switch op {
+		case "==":
+			return toString(left) == toString(right) && (left == nil) == (right == nil), nil
+		case "!=":
+			return toString(left) != toString(right) || (left == nil) != (right == nil), nil
+		default:
+			return false, fmt.Errorf("operator %s not supported for %v and %v", op, left, right)
+		}
+	}
+
+	l, r := toString(left), toString(right)
+	switch op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	default:
+		return l >= r, nil
+	}
+}
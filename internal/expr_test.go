@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderTemplate_Expressions(t *testing.T) {
+	ctx := map[string]any{
+		"page":                    1,
+		"price":                   100.0,
+		"name":                    "Alice",
+		"token":                   "user:pass",
+		"items":                   []any{"a", "b", "c"},
+		"user":                    map[string]any{"tags": []any{"x", "y"}},
+		"create-user.response.id": 7,
+		"empty":                   "",
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "arithmetic", input: "{{ page + 1 }}", expected: "2"},
+		{name: "precedence", input: "{{ 2 + page * 3 }}", expected: "5"},
+		{name: "parentheses", input: "{{ (2 + page) * 3 }}", expected: "9"},
+		{name: "float multiplication", input: "{{ price * 1.5 }}", expected: "150"},
+		{name: "integer division with remainder", input: "{{ 7 / 2 }}", expected: "3.5"},
+		{name: "modulo", input: "{{ 7 % 3 }}", expected: "1"},
+		{name: "unary minus", input: "{{ -page }}", expected: "-1"},
+		{name: "upper filter", input: "{{ name | upper }}", expected: "ALICE"},
+		{name: "chained filters", input: "{{ name | lower | len }}", expected: "5"},
+		{name: "base64 filter", input: "Basic {{ token | base64 }}", expected: "Basic dXNlcjpwYXNz"},
+		{name: "len of list", input: "{{ items | len }}", expected: "3"},
+		{name: "function call", input: "{{ len(items) }}", expected: "3"},
+		{name: "filter with arguments", input: "{{ items | join(\"-\") }}", expected: "a-b-c"},
+		{name: "default for missing", input: "{{ missing | default('none') }}", expected: "none"},
+		{name: "default for empty", input: "{{ empty | default('none') }}", expected: "none"},
+		{name: "string concatenation", input: "{{ name + '-' + page }}", expected: "Alice-1"},
+		{name: "comparison", input: "{{ page >= 1 && name == 'Alice' }}", expected: "true"},
+		{name: "path into context value", input: "{{ user.tags[1] | upper }}", expected: "Y"},
+		{name: "legacy key with dash", input: "{{create-user.response.id}}", expected: "7"},
+		{name: "faker call in expression", input: "{{ faker.int(5, 5) * 2 }}", expected: "10"},
+		{name: "missing kept", input: "{{ missing + 1 }}", expected: "{{ missing + 1 }}"},
+		{name: "invalid expression kept", input: "{{ page + }}", expected: "{{ page + }}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderTemplate(tt.input, ctx)
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateWithOptions_ExpressionErrors(t *testing.T) {
+	opts := RenderOptions{Mode: RenderModeStrict}
+
+	_, err := RenderTemplateWithOptions("{{ page + offset }}", map[string]any{"page": 1}, opts)
+	var unresolved *UnresolvedPlaceholdersError
+	if !errors.As(err, &unresolved) || !reflect.DeepEqual(unresolved.Keys, []string{"offset"}) {
+		t.Errorf("Expected unresolved offset, got %v", err)
+	}
+
+	_, err = RenderTemplateWithOptions("{{ page | nosuchfilter }}", map[string]any{"page": 1}, opts)
+	if err == nil || errors.As(err, &unresolved) {
+		t.Errorf("Expected unknown function error, got %v", err)
+	}
+
+	_, err = RenderTemplateWithOptions("{{ page / 0 }}", map[string]any{"page": 1}, opts)
+	if err == nil {
+		t.Error("Expected division by zero error")
+	}
+}
+
+func TestEvaluateCondition_Expressions(t *testing.T) {
+	ctx := map[string]any{
+		"page":   2,
+		"status": "active",
+		"items":  []any{1, 2},
+		"user":   map[string]any{"id": float64(7)},
+	}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{condition: "page + 1 > 2", want: true},
+		{condition: "status == 'active' && len(items) == 2", want: true},
+		{condition: "not (status == 'active') || page > 5", want: false},
+		{condition: "{{ page * 2 == 4 }}", want: true},
+		{condition: "{{ page + 1 }} == 3", want: true},
+		{condition: "{{status}} == active && {{page}} > 1", want: true},
+		{condition: "{{status}} == active && {{page}} > 2", want: false},
+		{condition: "{{page}} > 1 || {{status}} == inactive", want: true},
+		{condition: "{{user.id}} == 7 && len({{items}}) == 2", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			got, err := EvaluateCondition(tt.condition, ctx)
+			if err != nil {
+				t.Fatalf("EvaluateCondition() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateCondition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, condition := range []string{"missing == null", "stauts == foo", "status == active", "{{missing}} == active"} {
+		for _, mode := range []RenderMode{RenderModePermissive, RenderModeStrict} {
+			if _, err := EvaluateConditionWithOptions(condition, ctx, RenderOptions{Mode: mode}); err == nil {
+				t.Errorf("Expected error for unresolved identifier in %q (mode %d)", condition, mode)
+			}
+		}
+	}
+}
+
+func TestExpandLoop_ItemsExpression(t *testing.T) {
+	var loop LoopConfig
+	if err := yaml.Unmarshal([]byte(`{items: "{{ ids }}", var: id}`), &loop); err != nil {
+		t.Fatalf("Failed to unmarshal loop: %v", err)
+	}
+
+	contexts, err := ExpandLoop(&loop, map[string]any{"ids": []any{float64(1), float64(2)}})
+	if err != nil {
+		t.Fatalf("ExpandLoop() error = %v", err)
+	}
+	if len(contexts) != 2 || contexts[1]["id"] != float64(2) {
+		t.Errorf("Expected two items from ids, got %v", contexts)
+	}
+}
@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+)
+
+const (
+	ExtractFromBody   = "body"
+	ExtractFromHeader = "header"
+	ExtractFromStatus = "status"
+)
+
+const (
+	ExtractFirstRow = "first"
+	ExtractAllRows  = "all"
+)
+
+// ExtractValues captures the values described by specs from the response
+// and stores them in the context. Objects and arrays are kept as is and
+// additionally flattened, so both {{user}} and {{user.id}} resolve
+func ExtractValues(specs map[string]ExtractSpec, rec *httptest.ResponseRecorder, ctx map[string]any) error {
+	const op = "ExtractValues"
+
+	// Sorted for deterministic error reporting
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		val, err := extractValue(specs[name], rec)
+		if err != nil {
+			return NewError(ErrInvalidInput, op, "failed to extract value").
+				WithContext("variable", name).
+				WithContext("error", err.Error())
+		}
+
+		ctx[name] = val
+		switch val.(type) {
+		case map[string]any, []any:
+			extractJSONFields(name, val, ctx)
+		}
+	}
+
+	return nil
+}
+
+// extractValue captures a single value from the response
+func extractValue(spec ExtractSpec, rec *httptest.ResponseRecorder) (any, error) {
+	switch spec.From {
+	case ExtractFromStatus:
+		return rec.Code, nil
+
+	case ExtractFromHeader:
+		if spec.Name == "" {
+			return nil, fmt.Errorf("header name is required")
+		}
+		values := rec.Header().Values(spec.Name)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("header %s not found", spec.Name)
+		}
+		if spec.Regex != "" {
+			return matchRegex(spec.Regex, values[0])
+		}
+
+		return values[0], nil
+
+	case ExtractFromBody, "":
+		body := rec.Body.Bytes()
+		if spec.Regex != "" {
+			return matchRegex(spec.Regex, string(body))
+		}
+
+		var data any
+		if err := json.Unmarshal(body, &data); err != nil {
+			if spec.Path != "" {
+				return nil, fmt.Errorf("response body is not valid JSON: %w", err)
+			}
+
+			return string(body), nil
+		}
+
+		return ParseJSONPath(spec.Path, data)
+
+	default:
+		return nil, fmt.Errorf("unknown source %q (expected body, header or status)", spec.From)
+	}
+}
+
+// matchRegex returns the first capture group of the pattern or the whole match
+func matchRegex(pattern, input string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid regex pattern: %w", err)
+	}
+
+	m := re.FindStringSubmatch(input)
+	if m == nil {
+		return "", fmt.Errorf("regex %s did not match", pattern)
+	}
+	if len(m) > 1 {
+		return m[1], nil
+	}
+
+	return m[0], nil
+}
+
+// ExtractRows stores the rows returned by a dbCheck query in the context.
+// "first" stores one row as an object ({{code.value}}), "all" stores a list
+// of rows ({{codes[0].value}})
+func ExtractRows(specs map[string]DBExtractSpec, rows []map[string]any, ctx map[string]any) error {
+	const op = "ExtractRows"
+
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		val, err := extractRows(specs[name], rows)
+		if err != nil {
+			return NewError(ErrInvalidInput, op, "failed to extract rows").
+				WithContext("variable", name).
+				WithContext("error", err.Error())
+		}
+
+		ctx[name] = val
+		extractJSONFields(name, val, ctx)
+	}
+
+	return nil
+}
+
+// extractRows selects the rows and columns described by spec
+func extractRows(spec DBExtractSpec, rows []map[string]any) (any, error) {
+	selected := make([]any, len(rows))
+	for i, row := range rows {
+		if len(spec.Columns) == 0 {
+			selected[i] = map[string]any(row)
+
+			continue
+		}
+
+		picked := make(map[string]any, len(spec.Columns))
+		for _, col := range spec.Columns {
+			val, ok := row[col]
+			if !ok {
+				return nil, fmt.Errorf("column %s not found", col)
+			}
+			picked[col] = val
+		}
+		selected[i] = picked
+	}
+
+	switch spec.Rows {
+	case ExtractFirstRow, "":
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("query returned no rows")
+		}
+
+		return selected[0], nil
+	case ExtractAllRows:
+		return selected, nil
+	default:
+		return nil, fmt.Errorf("unknown rows %q (expected first or all)", spec.Rows)
+	}
+}
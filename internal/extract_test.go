@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestExtractValues(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "application/json")
+	rec.Header().Set("Location", "/users/42")
+	rec.WriteHeader(201)
+	_, _ = rec.WriteString(`{"data":{"token":"abc","roles":["admin","user"]},"link":"/confirm?id=77"}`)
+
+	tests := []struct {
+		name     string
+		spec     ExtractSpec
+		expected any
+		wantErr  bool
+	}{
+		{
+			name:     "body path",
+			spec:     ExtractSpec{From: "body", Path: "data.token"},
+			expected: "abc",
+		},
+		{
+			name:     "body array",
+			spec:     ExtractSpec{From: "body", Path: "data.roles"},
+			expected: []any{"admin", "user"},
+		},
+		{
+			name:     "body array element",
+			spec:     ExtractSpec{From: "body", Path: "data.roles[1]"},
+			expected: "user",
+		},
+		{
+			name:     "body regex",
+			spec:     ExtractSpec{From: "body", Regex: `id=(\d+)`},
+			expected: "77",
+		},
+		{
+			name:     "header",
+			spec:     ExtractSpec{From: "header", Name: "Location"},
+			expected: "/users/42",
+		},
+		{
+			name:     "header regex",
+			spec:     ExtractSpec{From: "header", Name: "Location", Regex: `/users/(\d+)`},
+			expected: "42",
+		},
+		{
+			name:     "status",
+			spec:     ExtractSpec{From: "status"},
+			expected: 201,
+		},
+		{
+			name:    "missing path",
+			spec:    ExtractSpec{From: "body", Path: "data.missing"},
+			wantErr: true,
+		},
+		{
+			name:    "missing header",
+			spec:    ExtractSpec{From: "header", Name: "X-Missing"},
+			wantErr: true,
+		},
+		{
+			name:    "regex without match",
+			spec:    ExtractSpec{From: "body", Regex: `code=(\d+)`},
+			wantErr: true,
+		},
+		{
+			name:    "unknown source",
+			spec:    ExtractSpec{From: "cookie"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := map[string]any{}
+			err := ExtractValues(map[string]ExtractSpec{"value": tt.spec}, rec, ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(ctx["value"], tt.expected) {
+				t.Errorf("Expected %#v, got %#v", tt.expected, ctx["value"])
+			}
+		})
+	}
+}
+
+func TestExtractValues_FlattensObjects(t *testing.T) {
+	rec := httptest.NewRecorder()
+	_, _ = rec.WriteString(`{"user":{"id":7,"tags":["a"]}}`)
+
+	ctx := map[string]any{}
+	err := ExtractValues(map[string]ExtractSpec{"user": {From: "body", Path: "user"}}, rec, ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, ok := ctx["user"].(map[string]any); !ok {
+		t.Errorf("Expected user to be stored as object, got %T", ctx["user"])
+	}
+	if ctx["user.id"] != float64(7) {
+		t.Errorf("Expected user.id = 7, got %v", ctx["user.id"])
+	}
+	if ctx["user.tags[0]"] != "a" {
+		t.Errorf("Expected user.tags[0] = a, got %v", ctx["user.tags[0]"])
+	}
+	if got := RenderTemplate("/users/{{user.id}}", ctx); got != "/users/7" {
+		t.Errorf("Expected rendered path /users/7, got %s", got)
+	}
+}
+
+func TestExtractRows(t *testing.T) {
+	rows := []map[string]any{
+		{"code": "123456", "email": "a@example.com"},
+		{"code": "654321", "email": "b@example.com"},
+	}
+
+	tests := []struct {
+		name     string
+		spec     DBExtractSpec
+		key      string
+		expected any
+		wantErr  bool
+	}{
+		{name: "first row", spec: DBExtractSpec{}, key: "v.code", expected: "123456"},
+		{name: "all rows", spec: DBExtractSpec{Rows: ExtractAllRows}, key: "v[1].email", expected: "b@example.com"},
+		{
+			name:     "selected columns",
+			spec:     DBExtractSpec{Columns: []string{"code"}},
+			key:      "v",
+			expected: map[string]any{"code": "123456"},
+		},
+		{name: "unknown column", spec: DBExtractSpec{Columns: []string{"token"}}, wantErr: true},
+		{name: "unknown rows", spec: DBExtractSpec{Rows: "last"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := map[string]any{}
+			err := ExtractRows(map[string]DBExtractSpec{"v": tt.spec}, rows, ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(ctx[tt.key], tt.expected) {
+				t.Errorf("Expected %s = %#v, got %#v", tt.key, tt.expected, ctx[tt.key])
+			}
+		})
+	}
+
+	if err := ExtractRows(map[string]DBExtractSpec{"v": {}}, nil, map[string]any{}); err == nil {
+		t.Error("Expected error when no rows are returned")
+	}
+}
@@ -1,27 +1,96 @@
 package internal
 
 import (
-	"crypto/rand"
+	crand "crypto/rand"
+	"encoding/binary"
 	"fmt"
-	"math/big"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// fakerPrefix starts every faker placeholder key: {{faker.email}}
+const fakerPrefix = "faker."
+
+// fakerPlaceholderRe matches faker placeholders only
+var fakerPlaceholderRe = regexp.MustCompile(`\{\{\s*(faker\.` + placeholderKey + `)\s*\}\}`)
+
+// defaultFakerRegistry serves faker placeholders when no registry is configured
+var defaultFakerRegistry = NewFakerRegistry()
+
+// fakerSource serves generators when no case source is configured. It is
+// randomly seeded, so its values are not reproducible
+var fakerSource = NewFakerSource(NewFakerSeed(), 0)
+
+// FakerSource is the random source of the generators, safe for concurrent
+// use. Every case draws from its own source so that seeded values do not
+// depend on the order in which cases and mock handlers run
+type FakerSource struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// NewFakerSource returns the source for the case at index in a suite seeded
+// with seed: the same seed and index produce the same sequence of values
+func NewFakerSource(seed int64, index int) *FakerSource {
+	stream := (uint64(seed) ^ 0x9e3779b97f4a7c15) + uint64(index)
+
+	return &FakerSource{r: rand.New(rand.NewPCG(uint64(seed), stream))}
+}
+
+func (s *FakerSource) int64N(n int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.r.Int64N(n)
+}
+
+// Read fills p with random bytes, so the source can feed uuid generation
+func (s *FakerSource) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range p {
+		p[i] = byte(s.r.Uint32())
+	}
+
+	return len(p), nil
+}
+
+// NewFakerSeed returns a random positive seed
+func NewFakerSeed() int64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		return time.Now().UnixNano()
+	}
+
+	return int64(binary.BigEndian.Uint64(b[:]) >> 1)
+}
+
 // FakerRegistry holds all faker functions
 type FakerRegistry struct {
-	functions map[string]FakerFunc
+	functions map[string]fakerFunc
 }
 
+// fakerFunc is a registered generator drawing from the source of the case
+type fakerFunc func(src *FakerSource, args ...string) (string, error)
+
 // FakerFunc is a function that generates fake data
 type FakerFunc func() string
 
+// FakerParamFunc generates fake data from the arguments of a call
+// placeholder such as {{faker.int(1,100)}}
+type FakerParamFunc func(args ...string) (string, error)
+
 // NewFakerRegistry creates a new faker registry with default functions
 func NewFakerRegistry() *FakerRegistry {
 	registry := &FakerRegistry{
-		functions: make(map[string]FakerFunc),
+		functions: make(map[string]fakerFunc),
 	}
 
 	// Register default functions
@@ -33,85 +102,143 @@ func NewFakerRegistry() *FakerRegistry {
 // RegisterDefaults registers all default faker functions
 func (r *FakerRegistry) RegisterDefaults() {
 	// UUID
-	r.Register("uuid", GenerateUUID)
-	r.Register("uuid.v4", GenerateUUID)
+	r.register("uuid", (*FakerSource).GenerateUUID)
+	r.register("uuid.v4", (*FakerSource).GenerateUUID)
 
 	// Names
-	r.Register("name", GenerateName)
-	r.Register("firstName", GenerateFirstName)
-	r.Register("lastName", GenerateLastName)
-	r.Register("fullName", GenerateName)
+	r.register("name", (*FakerSource).GenerateName)
+	r.register("firstName", (*FakerSource).GenerateFirstName)
+	r.register("lastName", (*FakerSource).GenerateLastName)
+	r.register("fullName", (*FakerSource).GenerateName)
 
 	// Internet
-	r.Register("email", GenerateEmail)
-	r.Register("username", GenerateUsername)
-	r.Register("domain", GenerateDomain)
-	r.Register("url", GenerateURL)
-	r.Register("ipv4", GenerateIPv4)
+	r.register("email", (*FakerSource).GenerateEmail)
+	r.register("username", (*FakerSource).GenerateUsername)
+	r.register("domain", (*FakerSource).GenerateDomain)
+	r.register("url", (*FakerSource).GenerateURL)
+	r.register("ipv4", (*FakerSource).GenerateIPv4)
 
 	// Phone
-	r.Register("phone", GeneratePhone)
-	r.Register("phoneNumber", GeneratePhone)
+	r.register("phone", (*FakerSource).GeneratePhone)
+	r.register("phoneNumber", (*FakerSource).GeneratePhone)
 
 	// Address
-	r.Register("city", GenerateCity)
-	r.Register("street", GenerateStreet)
-	r.Register("country", GenerateCountry)
-	r.Register("zipCode", GenerateZipCode)
+	r.register("city", (*FakerSource).GenerateCity)
+	r.register("street", (*FakerSource).GenerateStreet)
+	r.register("country", (*FakerSource).GenerateCountry)
+	r.register("zipCode", (*FakerSource).GenerateZipCode)
 
 	// Date/Time
-	r.Register("date", GenerateDate)
-	r.Register("time", GenerateTime)
-	r.Register("timestamp", GenerateTimestamp)
-	r.Register("now", GenerateNow)
+	r.registerFunc("date", (*FakerSource).GenerateDateBetween)
+	r.register("time", (*FakerSource).GenerateTime)
+	r.register("timestamp", (*FakerSource).GenerateTimestamp)
+	r.register("now", (*FakerSource).GenerateNow)
 
 	// Numbers
-	r.Register("number", GenerateNumber)
-	r.Register("integer", GenerateInteger)
-	r.Register("float", GenerateFloat)
-	r.Register("digit", GenerateDigit)
+	r.register("number", (*FakerSource).GenerateNumber)
+	r.register("integer", (*FakerSource).GenerateInteger)
+	r.register("float", (*FakerSource).GenerateFloat)
+	r.register("digit", (*FakerSource).GenerateDigit)
 
 	// Text
-	r.Register("word", GenerateWord)
-	r.Register("words", GenerateWords)
-	r.Register("sentence", GenerateSentence)
-	r.Register("paragraph", GenerateParagraph)
+	r.register("word", (*FakerSource).GenerateWord)
+	r.register("words", (*FakerSource).GenerateWords)
+	r.register("sentence", (*FakerSource).GenerateSentence)
+	r.register("paragraph", (*FakerSource).GenerateParagraph)
 
 	// Company
-	r.Register("company", GenerateCompany)
-	r.Register("companyName", GenerateCompany)
+	r.register("company", (*FakerSource).GenerateCompany)
+	r.register("companyName", (*FakerSource).GenerateCompany)
 
 	// Random
-	r.Register("random.string", GenerateRandomString)
-	r.Register("random.int", GenerateRandomInt)
-	r.Register("random.bool", GenerateRandomBool)
+	r.register("random.string", (*FakerSource).GenerateRandomString)
+	r.register("random.int", (*FakerSource).GenerateRandomInt)
+	r.register("random.bool", (*FakerSource).GenerateRandomBool)
+
+	// Parameterised
+	r.registerFunc("int", (*FakerSource).GenerateIntBetween)
+	r.registerFunc("string", (*FakerSource).GenerateStringOfLength)
+	r.registerFunc("oneOf", (*FakerSource).GenerateOneOf)
 }
 
 // Register adds a custom faker function
 func (r *FakerRegistry) Register(name string, fn FakerFunc) {
+	r.register(name, func(*FakerSource) string { return fn() })
+}
+
+// RegisterFunc adds a custom faker function that accepts arguments
+func (r *FakerRegistry) RegisterFunc(name string, fn FakerParamFunc) {
+	r.registerFunc(name, func(_ *FakerSource, args ...string) (string, error) { return fn(args...) })
+}
+
+func (r *FakerRegistry) register(name string, fn func(src *FakerSource) string) {
+	r.functions[name] = func(src *FakerSource, args ...string) (string, error) {
+		if len(args) > 0 {
+			return "", fmt.Errorf("faker function %s takes no arguments", name)
+		}
+
+		return fn(src), nil
+	}
+}
+
+func (r *FakerRegistry) registerFunc(name string, fn fakerFunc) {
 	r.functions[name] = fn
 }
 
 // Generate generates fake data using the specified function name
-func (r *FakerRegistry) Generate(name string) (string, error) {
+func (r *FakerRegistry) Generate(name string, args ...string) (string, error) {
+	return r.generate(fakerSource, name, args...)
+}
+
+// generate generates fake data drawing from src
+func (r *FakerRegistry) generate(src *FakerSource, name string, args ...string) (string, error) {
 	fn, exists := r.functions[name]
 	if !exists {
 		return "", fmt.Errorf("faker function not found: %s", name)
 	}
 
-	return fn(), nil
+	return fn(src, args...)
+}
+
+// resolve generates the value for a faker placeholder key. A key with an
+// alias ({{faker.email#alice}}) stores the value in the context under the
+// full key and the alias, so it can be reused later in the case
+func (r *FakerRegistry) resolve(key string, ctx map[string]any, src *FakerSource) (any, bool) {
+	call, alias := splitFakerAlias(strings.TrimPrefix(key, fakerPrefix))
+
+	name, args, err := parseFakerCall(call)
+	if err != nil {
+		return nil, false
+	}
+
+	val, err := r.generate(src, name, args...)
+	if err != nil {
+		return nil, false
+	}
+
+	if alias != "" {
+		ctx[key] = val
+		ctx[alias] = val
+	}
+
+	return val, true
 }
 
 // === Generator Functions ===
 
 // GenerateUUID generates a random UUID
-func GenerateUUID() string {
-	return uuid.New().String()
+func (s *FakerSource) GenerateUUID() string {
+	id, err := uuid.NewRandomFromReader(s)
+	if err != nil {
+		return uuid.New().String()
+	}
+
+	return id.String()
 }
 
 // GenerateName generates a random full name
-func GenerateName() string {
-	return GenerateFirstName() + " " + GenerateLastName()
+func (s *FakerSource) GenerateName() string {
+	return s.GenerateFirstName() + " " + s.GenerateLastName()
 }
 
 var firstNames = []string{
@@ -127,57 +254,57 @@ var lastNames = []string{
 }
 
 // GenerateFirstName generates a random first name
-func GenerateFirstName() string {
-	return randomChoice(firstNames)
+func (s *FakerSource) GenerateFirstName() string {
+	return s.randomChoice(firstNames)
 }
 
 // GenerateLastName generates a random last name
-func GenerateLastName() string {
-	return randomChoice(lastNames)
+func (s *FakerSource) GenerateLastName() string {
+	return s.randomChoice(lastNames)
 }
 
 // GenerateEmail generates a random email address
-func GenerateEmail() string {
-	first := strings.ToLower(GenerateFirstName())
-	last := strings.ToLower(GenerateLastName())
-	domain := GenerateDomain()
+func (s *FakerSource) GenerateEmail() string {
+	first := strings.ToLower(s.GenerateFirstName())
+	last := strings.ToLower(s.GenerateLastName())
+	domain := s.GenerateDomain()
 	return fmt.Sprintf("%s.%s@%s", first, last, domain)
 }
 
 // GenerateUsername generates a random username
-func GenerateUsername() string {
-	first := strings.ToLower(GenerateFirstName())
-	number := randomInt(100, 999)
+func (s *FakerSource) GenerateUsername() string {
+	first := strings.ToLower(s.GenerateFirstName())
+	number := s.randomInt(100, 999)
 	return fmt.Sprintf("%s%d", first, number)
 }
 
 var domains = []string{"example.com", "test.com", "mail.com", "email.com", "demo.com"}
 
 // GenerateDomain generates a random domain
-func GenerateDomain() string {
-	return randomChoice(domains)
+func (s *FakerSource) GenerateDomain() string {
+	return s.randomChoice(domains)
 }
 
 // GenerateURL generates a random URL
-func GenerateURL() string {
-	return "https://" + GenerateDomain()
+func (s *FakerSource) GenerateURL() string {
+	return "https://" + s.GenerateDomain()
 }
 
 // GenerateIPv4 generates a random IPv4 address
-func GenerateIPv4() string {
+func (s *FakerSource) GenerateIPv4() string {
 	return fmt.Sprintf("%d.%d.%d.%d",
-		randomInt(1, 255),
-		randomInt(0, 255),
-		randomInt(0, 255),
-		randomInt(0, 255))
+		s.randomInt(1, 255),
+		s.randomInt(0, 255),
+		s.randomInt(0, 255),
+		s.randomInt(0, 255))
 }
 
 // GeneratePhone generates a random phone number
-func GeneratePhone() string {
+func (s *FakerSource) GeneratePhone() string {
 	return fmt.Sprintf("+1-%03d-%03d-%04d",
-		randomInt(100, 999),
-		randomInt(100, 999),
-		randomInt(1000, 9999))
+		s.randomInt(100, 999),
+		s.randomInt(100, 999),
+		s.randomInt(1000, 9999))
 }
 
 var cities = []string{
@@ -186,8 +313,8 @@ var cities = []string{
 }
 
 // GenerateCity generates a random city name
-func GenerateCity() string {
-	return randomChoice(cities)
+func (s *FakerSource) GenerateCity() string {
+	return s.randomChoice(cities)
 }
 
 var streets = []string{
@@ -196,9 +323,9 @@ var streets = []string{
 }
 
 // GenerateStreet generates a random street name
-func GenerateStreet() string {
-	number := randomInt(1, 9999)
-	street := randomChoice(streets)
+func (s *FakerSource) GenerateStreet() string {
+	number := s.randomInt(1, 9999)
+	street := s.randomChoice(streets)
 	return fmt.Sprintf("%d %s", number, street)
 }
 
@@ -208,62 +335,62 @@ var countries = []string{
 }
 
 // GenerateCountry generates a random country name
-func GenerateCountry() string {
-	return randomChoice(countries)
+func (s *FakerSource) GenerateCountry() string {
+	return s.randomChoice(countries)
 }
 
 // GenerateZipCode generates a random ZIP code
-func GenerateZipCode() string {
-	return fmt.Sprintf("%05d", randomInt(10000, 99999))
+func (s *FakerSource) GenerateZipCode() string {
+	return fmt.Sprintf("%05d", s.randomInt(10000, 99999))
 }
 
 // GenerateDate generates a random date
-func GenerateDate() string {
-	days := randomInt(0, 365)
+func (s *FakerSource) GenerateDate() string {
+	days := s.randomInt(0, 365)
 	date := time.Now().AddDate(0, 0, -days)
 	return date.Format("2006-01-02")
 }
 
 // GenerateTime generates a random time
-func GenerateTime() string {
-	hour := randomInt(0, 23)
-	minute := randomInt(0, 59)
-	second := randomInt(0, 59)
+func (s *FakerSource) GenerateTime() string {
+	hour := s.randomInt(0, 23)
+	minute := s.randomInt(0, 59)
+	second := s.randomInt(0, 59)
 	return fmt.Sprintf("%02d:%02d:%02d", hour, minute, second)
 }
 
 // GenerateTimestamp generates a random timestamp
-func GenerateTimestamp() string {
-	days := randomInt(0, 365)
+func (s *FakerSource) GenerateTimestamp() string {
+	days := s.randomInt(0, 365)
 	timestamp := time.Now().AddDate(0, 0, -days)
 	return timestamp.Format(time.RFC3339)
 }
 
 // GenerateNow generates the current timestamp
-func GenerateNow() string {
+func (s *FakerSource) GenerateNow() string {
 	return time.Now().Format(time.RFC3339)
 }
 
 // GenerateNumber generates a random number string
-func GenerateNumber() string {
-	return fmt.Sprintf("%d", randomInt(1, 1000000))
+func (s *FakerSource) GenerateNumber() string {
+	return fmt.Sprintf("%d", s.randomInt(1, 1000000))
 }
 
 // GenerateInteger generates a random integer string
-func GenerateInteger() string {
-	return GenerateNumber()
+func (s *FakerSource) GenerateInteger() string {
+	return s.GenerateNumber()
 }
 
 // GenerateFloat generates a random float string
-func GenerateFloat() string {
-	integer := randomInt(1, 10000)
-	decimal := randomInt(0, 99)
+func (s *FakerSource) GenerateFloat() string {
+	integer := s.randomInt(1, 10000)
+	decimal := s.randomInt(0, 99)
 	return fmt.Sprintf("%d.%02d", integer, decimal)
 }
 
 // GenerateDigit generates a random single digit
-func GenerateDigit() string {
-	return fmt.Sprintf("%d", randomInt(0, 9))
+func (s *FakerSource) GenerateDigit() string {
+	return fmt.Sprintf("%d", s.randomInt(0, 9))
 }
 
 var words = []string{
@@ -273,32 +400,32 @@ var words = []string{
 }
 
 // GenerateWord generates a random word
-func GenerateWord() string {
-	return randomChoice(words)
+func (s *FakerSource) GenerateWord() string {
+	return s.randomChoice(words)
 }
 
 // GenerateWords generates multiple random words
-func GenerateWords() string {
-	count := randomInt(3, 8)
+func (s *FakerSource) GenerateWords() string {
+	count := s.randomInt(3, 8)
 	result := make([]string, count)
 	for i := 0; i < count; i++ {
-		result[i] = GenerateWord()
+		result[i] = s.GenerateWord()
 	}
 	return strings.Join(result, " ")
 }
 
 // GenerateSentence generates a random sentence
-func GenerateSentence() string {
-	sentence := GenerateWords()
+func (s *FakerSource) GenerateSentence() string {
+	sentence := s.GenerateWords()
 	return strings.ToUpper(string(sentence[0])) + sentence[1:] + "."
 }
 
 // GenerateParagraph generates a random paragraph
-func GenerateParagraph() string {
-	sentences := randomInt(3, 6)
+func (s *FakerSource) GenerateParagraph() string {
+	sentences := s.randomInt(3, 6)
 	result := make([]string, sentences)
 	for i := 0; i < sentences; i++ {
-		result[i] = GenerateSentence()
+		result[i] = s.GenerateSentence()
 	}
 	return strings.Join(result, " ")
 }
@@ -309,57 +436,243 @@ var companies = []string{
 }
 
 // GenerateCompany generates a random company name
-func GenerateCompany() string {
-	return randomChoice(companies)
+func (s *FakerSource) GenerateCompany() string {
+	return s.randomChoice(companies)
 }
 
 // GenerateRandomString generates a random alphanumeric string
-func GenerateRandomString() string {
-	length := randomInt(8, 16)
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	result := make([]byte, length)
-	for i := range result {
-		n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
-		result[i] = charset[n.Int64()]
-	}
-	return string(result)
+func (s *FakerSource) GenerateRandomString() string {
+	return s.randomString(s.randomInt(8, 16))
 }
 
 // GenerateRandomInt generates a random integer string
-func GenerateRandomInt() string {
-	return fmt.Sprintf("%d", randomInt(1, 1000000))
+func (s *FakerSource) GenerateRandomInt() string {
+	return fmt.Sprintf("%d", s.randomInt(1, 1000000))
 }
 
 // GenerateRandomBool generates a random boolean string
-func GenerateRandomBool() string {
-	if randomInt(0, 1) == 0 {
+func (s *FakerSource) GenerateRandomBool() string {
+	if s.randomInt(0, 1) == 0 {
 		return "false"
 	}
 	return "true"
 }
 
+// === Parameterised Generator Functions ===
+
+// GenerateIntBetween generates a random integer in [min, max]: {{faker.int(1,100)}}
+func (s *FakerSource) GenerateIntBetween(args ...string) (string, error) {
+	if len(args) != 2 {
+		return "", fmt.Errorf("faker.int expects (min, max), got %d arguments", len(args))
+	}
+
+	lo, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "", fmt.Errorf("faker.int: invalid min %q", args[0])
+	}
+	hi, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "", fmt.Errorf("faker.int: invalid max %q", args[1])
+	}
+	if lo > hi {
+		return "", fmt.Errorf("faker.int: min %d is greater than max %d", lo, hi)
+	}
+
+	return strconv.Itoa(s.randomInt(lo, hi)), nil
+}
+
+// GenerateStringOfLength generates a random alphanumeric string of the given
+// length: {{faker.string(12)}}
+func (s *FakerSource) GenerateStringOfLength(args ...string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("faker.string expects (length), got %d arguments", len(args))
+	}
+
+	length, err := strconv.Atoi(args[0])
+	if err != nil || length < 0 {
+		return "", fmt.Errorf("faker.string: invalid length %q", args[0])
+	}
+
+	return s.randomString(length), nil
+}
+
+// GenerateDateBetween generates a random date between two bounds:
+// {{faker.date("2006-01-02", "-30d", "+30d")}}. Bounds are offsets from now
+// ("-30d", "+2h", "now") or dates in the given layout. Without arguments it
+// behaves like GenerateDate
+func (s *FakerSource) GenerateDateBetween(args ...string) (string, error) {
+	if len(args) > 3 {
+		return "", fmt.Errorf("faker.date expects (layout, from, to), got %d arguments", len(args))
+	}
+
+	params := []string{"2006-01-02", "-365d", "now"}
+	copy(params, args)
+	layout := params[0]
+
+	now := time.Now()
+	from, err := parseFakerTime(params[1], layout, now)
+	if err != nil {
+		return "", err
+	}
+	to, err := parseFakerTime(params[2], layout, now)
+	if err != nil {
+		return "", err
+	}
+	if to.Before(from) {
+		return "", fmt.Errorf("faker.date: %q is before %q", params[2], params[1])
+	}
+
+	span := int64(to.Sub(from) / time.Second)
+	date := from.Add(time.Duration(s.int64N(span+1)) * time.Second)
+
+	return date.Format(layout), nil
+}
+
+// GenerateOneOf picks one of its arguments: {{faker.oneOf(a,b,c)}}
+func (s *FakerSource) GenerateOneOf(args ...string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("faker.oneOf expects at least one argument")
+	}
+
+	return s.randomChoice(args), nil
+}
+
 // === Helper Functions ===
 
-func randomChoice(choices []string) string {
+func (s *FakerSource) randomString(length int) string {
+	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	result := make([]byte, length)
+	for i := range result {
+		result[i] = charset[s.randomInt(0, len(charset)-1)]
+	}
+	return string(result)
+}
+
+// parseFakerTime parses a date bound: "now", an offset such as "-30d" or
+// "+2h", or an absolute date in layout
+func parseFakerTime(s, layout string, now time.Time) (time.Time, error) {
+	if s == "" || s == "now" {
+		return now, nil
+	}
+
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, n), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(d), nil
+	}
+
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("faker.date: invalid bound %q", s)
+	}
+
+	return t, nil
+}
+
+// splitFakerAlias splits "int(1,10)#n" into the call and its alias. The
+// alias separator is searched after the argument list
+func splitFakerAlias(key string) (call, alias string) {
+	start := strings.LastIndex(key, ")") + 1
+	if i := strings.Index(key[start:], "#"); i >= 0 {
+		return key[:start+i], key[start+i+1:]
+	}
+
+	return key, ""
+}
+
+// parseFakerCall parses a generator call such as int(1, 100) or
+// date("2006-01-02", "-30d", "+30d"). Arguments may be quoted with single
+// or double quotes to include commas or spaces
+func parseFakerCall(call string) (string, []string, error) {
+	open := strings.Index(call, "(")
+	if open < 0 {
+		return call, nil, nil
+	}
+	if !strings.HasSuffix(call, ")") {
+		return "", nil, fmt.Errorf("invalid faker call: %s", call)
+	}
+
+	name := call[:open]
+	inner := strings.TrimSpace(call[open+1 : len(call)-1])
+	if inner == "" {
+		return name, nil, nil
+	}
+
+	var (
+		args    []string
+		current strings.Builder
+		quote   rune
+	)
+	for _, c := range inner {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(c)
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			args = append(args, strings.TrimSpace(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(c)
+		}
+	}
+	if quote != 0 {
+		return "", nil, fmt.Errorf("unterminated quote in faker call: %s", call)
+	}
+	args = append(args, strings.TrimSpace(current.String()))
+
+	return name, args, nil
+}
+
+func (s *FakerSource) randomChoice(choices []string) string {
 	if len(choices) == 0 {
 		return ""
 	}
-	n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(choices))))
-	return choices[n.Int64()]
+	return choices[s.randomInt(0, len(choices)-1)]
 }
 
-func randomInt(min, max int) int {
+func (s *FakerSource) randomInt(min, max int) int {
 	if min >= max {
 		return min
 	}
-	n, _ := rand.Int(rand.Reader, big.NewInt(int64(max-min+1)))
-	return int(n.Int64()) + min
+	return int(s.int64N(int64(max-min)+1)) + min
 }
 
 // ExpandFakerPlaceholders expands faker placeholders in a string
 // Format: {{faker.functionName}}
 func ExpandFakerPlaceholders(input string, registry *FakerRegistry) string {
-	// Simple replacement - in real implementation would use regex
-	// For now, this is a placeholder for the concept
-	return input
+	expanded, _ := expandFakerInString(input, map[string]any{}, RenderOptions{Faker: registry})
+
+	return expanded
+}
+
+// expandFakerInString replaces faker placeholders only, leaving other
+// placeholders for later rendering
+func expandFakerInString(input string, ctx map[string]any, opts RenderOptions) (string, error) {
+	opts.Mode = RenderModePermissive
+	opts.DefaultValue = ""
+
+	return replacePlaceholders(input, fakerPlaceholderRe, ctx, opts, func(val any) (string, error) {
+		return fmt.Sprintf("%+v", val), nil
+	})
+}
+
+// expandFakerInContext expands faker placeholders in context values, such as
+// case variables declared as "{{faker.email}}". Keys are walked in order so
+// that seeded values are reproducible
+func expandFakerInContext(ctx map[string]any, opts RenderOptions) {
+	for _, k := range sortedKeys(ctx) {
+		val, ok := ctx[k].(string)
+		if !ok || !strings.Contains(val, "{{"+fakerPrefix) {
+			continue
+		}
+
+		ctx[k], _ = expandFakerInString(val, ctx, opts)
+	}
 }
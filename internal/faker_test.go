@@ -0,0 +1,257 @@
+package internal
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderTemplate_FakerPlaceholders(t *testing.T) {
+	ctx := map[string]any{}
+
+	got := RenderTemplate("/users/{{faker.uuid}}?email={{faker.email}}", ctx)
+	if strings.Contains(got, "{{") {
+		t.Fatalf("Expected faker placeholders to be expanded, got %s", got)
+	}
+	if !strings.Contains(got, "@") {
+		t.Errorf("Expected generated email in %s", got)
+	}
+	if len(ctx) != 0 {
+		t.Errorf("Placeholders without alias must not be stored, got %v", ctx)
+	}
+}
+
+func TestRenderTemplate_FakerAlias(t *testing.T) {
+	ctx := map[string]any{}
+
+	first := RenderTemplate("{{faker.email#alice}}", ctx)
+	second := RenderTemplate("{{faker.email#alice}}", ctx)
+	byAlias := RenderTemplate("{{alice}}", ctx)
+
+	if !strings.Contains(first, "@") {
+		t.Fatalf("Expected generated email, got %s", first)
+	}
+	if second != first {
+		t.Errorf("Expected aliased value to be reused, got %s and %s", first, second)
+	}
+	if byAlias != first {
+		t.Errorf("Expected alias to resolve to %s, got %s", first, byAlias)
+	}
+}
+
+func TestRenderAny_FakerInBody(t *testing.T) {
+	ctx := map[string]any{}
+
+	body := RenderAny(map[string]any{
+		"name":  "{{faker.name#user}}",
+		"count": "{{faker.digit | int}}",
+		"zip":   "{{faker.zipCode}}",
+	}, ctx).(map[string]any)
+
+	if body["name"] != ctx["user"] {
+		t.Errorf("Expected name %v to be stored as user, got %v", body["name"], ctx["user"])
+	}
+	if _, ok := body["count"].(int64); !ok {
+		t.Errorf("Expected digit to be cast to number, got %T", body["count"])
+	}
+	if _, ok := body["zip"].(string); !ok {
+		t.Errorf("Expected zip code to stay a string, got %T", body["zip"])
+	}
+}
+
+func TestRenderTemplateWithOptions_UnknownFaker(t *testing.T) {
+	got, err := RenderTemplateWithOptions("{{faker.unknown}}", map[string]any{}, DefaultRenderOptions())
+	if err != nil || got != "{{faker.unknown}}" {
+		t.Errorf("Permissive mode: expected placeholder to be kept, got %q, %v", got, err)
+	}
+
+	_, err = RenderTemplateWithOptions("{{faker.unknown}}", map[string]any{}, RenderOptions{Mode: RenderModeStrict})
+	if err == nil {
+		t.Error("Strict mode: expected error for unknown faker function")
+	}
+}
+
+func TestExpandFakerPlaceholders(t *testing.T) {
+	registry := NewFakerRegistry()
+	registry.Register("fixed", func() string { return "value" })
+
+	got := ExpandFakerPlaceholders("{{faker.fixed}} {{other}}", registry)
+	if got != "value {{other}}" {
+		t.Errorf("Expected only faker placeholders to be expanded, got %q", got)
+	}
+}
+
+func TestExpandFakerInContext(t *testing.T) {
+	ctx := map[string]any{
+		"email": "{{faker.email#owner}}",
+		"plain": "{{other}}",
+		"count": 3,
+	}
+
+	expandFakerInContext(ctx, DefaultRenderOptions())
+
+	if ctx["email"] != ctx["owner"] || !strings.Contains(ctx["email"].(string), "@") {
+		t.Errorf("Expected email variable to be generated and aliased, got %v / %v", ctx["email"], ctx["owner"])
+	}
+	if ctx["plain"] != "{{other}}" {
+		t.Errorf("Non-faker placeholders must be kept, got %v", ctx["plain"])
+	}
+}
+
+func TestNewFakerSource_Reproducible(t *testing.T) {
+	const input = "{{faker.uuid}} {{faker.email}} {{faker.random.string}} {{faker.int(1,1000)}}"
+	generate := func(seed int64, index int) string {
+		opts := RenderOptions{Rand: NewFakerSource(seed, index)}
+		out, err := RenderTemplateWithOptions(input, map[string]any{}, opts)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		return out
+	}
+
+	first := generate(42, 0)
+	if second := generate(42, 0); first != second {
+		t.Errorf("Expected the same values for the same seed, got %q and %q", first, second)
+	}
+	if other := generate(43, 0); other == first {
+		t.Errorf("Expected different values for another seed, got %q", other)
+	}
+	if other := generate(42, 1); other == first {
+		t.Errorf("Expected different values for another case, got %q", other)
+	}
+}
+
+func TestNewFakerSource_ReproducibleMaps(t *testing.T) {
+	generate := func() (RequestSpec, map[string]any) {
+		opts := RenderOptions{Rand: NewFakerSource(42, 0)}
+
+		req, err := renderRequestWithOptions(RequestSpec{
+			Headers: map[string]string{"X-A": "{{faker.uuid}}", "X-B": "{{faker.uuid}}", "X-C": "{{faker.uuid}}"},
+			Body: map[string]any{
+				"a": "{{faker.email}}",
+				"b": "{{faker.email}}",
+				"c": "{{faker.email}}",
+				"d": map[string]any{"e": "{{faker.email}}", "f": "{{faker.email}}"},
+			},
+		}, map[string]any{}, opts)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		ctx := map[string]any{"x": "{{faker.email}}", "y": "{{faker.email}}", "z": "{{faker.email}}"}
+		expandFakerInContext(ctx, opts)
+
+		return req, ctx
+	}
+
+	firstReq, firstCtx := generate()
+	for i := 0; i < 20; i++ {
+		req, ctx := generate()
+		if !reflect.DeepEqual(req, firstReq) {
+			t.Fatalf("Expected the same request for the same seed, got %v and %v", firstReq, req)
+		}
+		if !reflect.DeepEqual(ctx, firstCtx) {
+			t.Fatalf("Expected the same variables for the same seed, got %v and %v", firstCtx, ctx)
+		}
+	}
+}
+
+func TestFakerRegistry_ParameterisedGenerators(t *testing.T) {
+	registry := NewFakerRegistry()
+
+	for i := 0; i < 50; i++ {
+		val, err := registry.Generate("int", "5", "7")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if n, _ := strconv.Atoi(val); n < 5 || n > 7 {
+			t.Fatalf("Expected value in [5, 7], got %s", val)
+		}
+	}
+
+	val, err := registry.Generate("string", "12")
+	if err != nil || len(val) != 12 {
+		t.Errorf("Expected string of length 12, got %q, %v", val, err)
+	}
+
+	val, err = registry.Generate("oneOf", "a", "b")
+	if err != nil || (val != "a" && val != "b") {
+		t.Errorf("Expected a or b, got %q, %v", val, err)
+	}
+
+	val, err = registry.Generate("date", "2006-01-02", "2024-03-01", "2024-03-01")
+	if err != nil || val != "2024-03-01" {
+		t.Errorf("Expected 2024-03-01, got %q, %v", val, err)
+	}
+
+	errorCases := [][]string{
+		{"int", "1"},
+		{"int", "10", "1"},
+		{"string", "x"},
+		{"oneOf"},
+		{"date", "2006-01-02", "+1d", "-1d"},
+		{"email", "1"},
+	}
+	for _, args := range errorCases {
+		if _, err := registry.Generate(args[0], args[1:]...); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
+
+func TestRenderTemplate_FakerCalls(t *testing.T) {
+	ctx := map[string]any{}
+
+	got := RenderTemplate(`{{faker.oneOf(red, "dark blue")#color}}`, ctx)
+	if got != "red" && got != "dark blue" {
+		t.Fatalf("Expected red or dark blue, got %q", got)
+	}
+	if ctx["color"] != got {
+		t.Errorf("Expected alias color to be %q, got %v", got, ctx["color"])
+	}
+
+	date := RenderTemplate(`{{faker.date("2006-01-02", "-30d", "+30d")}}`, ctx)
+	parsed, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		t.Fatalf("Expected a date, got %q", date)
+	}
+	if d := time.Until(parsed); d < -31*24*time.Hour || d > 31*24*time.Hour {
+		t.Errorf("Expected date within 30 days, got %s", date)
+	}
+
+	body := RenderAny(map[string]any{"n": "{{ faker.int(1,100) | int }}"}, ctx).(map[string]any)
+	if _, ok := body["n"].(int64); !ok {
+		t.Errorf("Expected faker.int to be cast to number, got %T", body["n"])
+	}
+}
+
+func TestParseFakerCall(t *testing.T) {
+	tests := []struct {
+		call    string
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{call: "email", name: "email"},
+		{call: "int(1, 100)", name: "int", args: []string{"1", "100"}},
+		{call: `date("2006-01-02", '-30d', "+30d")`, name: "date", args: []string{"2006-01-02", "-30d", "+30d"}},
+		{call: `oneOf("a,b", c)`, name: "oneOf", args: []string{"a,b", "c"}},
+		{call: "string()", name: "string"},
+		{call: `oneOf("a)`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.call, func(t *testing.T) {
+			name, args, err := parseFakerCall(tt.call)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFakerCall() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name != tt.name || !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("parseFakerCall() = %s %v, want %s %v", name, args, tt.name, tt.args)
+			}
+		})
+	}
+}
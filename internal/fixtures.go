@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rom8726/pgfixtures"
+	"gopkg.in/yaml.v3"
+)
+
+// fixtureTable holds the rows of one table in file order
+type fixtureTable struct {
+	name string
+	rows []map[string]any
+}
+
+// loadFixtureFile is the fixture loader used when pgfixtures cannot connect
+// by itself (caller-provided databases, SQLite). It deletes every row of the
+// tables in the file, in reverse order, then inserts the rows in file order.
+// A "$eval(expr)" value is inlined as an SQL expression
+func loadFixtureFile(ctx context.Context, db Database, dbType pgfixtures.DatabaseType, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	tables, err := parseFixtureTables(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i := len(tables) - 1; i >= 0; i-- {
+		if err := db.Exec(ctx, "DELETE FROM "+quoteIdent(dbType, tables[i].name)); err != nil {
+			return fmt.Errorf("failed to clear table %s: %w", tables[i].name, err)
+		}
+	}
+
+	for _, table := range tables {
+		for _, row := range table.rows {
+			query, args, err := fixtureInsert(dbType, table.name, row)
+			if err != nil {
+				return fmt.Errorf("table %s: %w", table.name, err)
+			}
+
+			if err := db.Exec(ctx, query, args...); err != nil {
+				return fmt.Errorf("failed to insert into %s: %w", table.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeInlineFixture renders inline fixtures and writes them to dir as one
+// fixture file. Rows of the same table are merged
+func writeInlineFixture(dir string, fixtures []Fixture, ctx map[string]any, opts RenderOptions) (string, error) {
+	var order []string
+	rowsByTable := make(map[string][]any)
+
+	for _, fixture := range fixtures {
+		if _, ok := rowsByTable[fixture.Table]; !ok {
+			order = append(order, fixture.Table)
+		}
+
+		for _, row := range fixture.Rows {
+			rendered, err := renderValue(map[string]any(row), ctx, opts)
+			if err != nil {
+				return "", fmt.Errorf("table %s: %w", fixture.Table, err)
+			}
+			rowsByTable[fixture.Table] = append(rowsByTable[fixture.Table], rendered)
+		}
+	}
+
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, table := range order {
+		var rows yaml.Node
+		if err := rows.Encode(rowsByTable[table]); err != nil {
+			return "", fmt.Errorf("table %s: %w", table, err)
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: table}, &rows)
+	}
+
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, "inline.yml")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// parseFixtureTables decodes a fixture document keeping the table order
+func parseFixtureTables(data []byte) ([]fixtureTable, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("fixture must be a mapping of table names to rows")
+	}
+
+	tables := make([]fixtureTable, 0, len(root.Content)/2)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		table := fixtureTable{name: root.Content[i].Value}
+		if err := root.Content[i+1].Decode(&table.rows); err != nil {
+			return nil, fmt.Errorf("table %s: %w", table.name, err)
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
+
+// fixtureInsert builds the INSERT statement for a fixture row
+func fixtureInsert(dbType pgfixtures.DatabaseType, table string, row map[string]any) (string, []any, error) {
+	cols := make([]string, 0, len(row))
+	for col := range row {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	names := make([]string, len(cols))
+	values := make([]string, len(cols))
+	var args []any
+
+	for i, col := range cols {
+		names[i] = quoteIdent(dbType, col)
+
+		val := row[col]
+		if s, ok := val.(string); ok && strings.HasPrefix(s, "$eval(") && strings.HasSuffix(s, ")") {
+			values[i] = "(" + strings.TrimSuffix(strings.TrimPrefix(s, "$eval("), ")") + ")"
+
+			continue
+		}
+
+		switch val.(type) {
+		case map[string]any, []any:
+			b, err := json.Marshal(val)
+			if err != nil {
+				return "", nil, fmt.Errorf("column %s: %w", col, err)
+			}
+			val = string(b)
+		}
+
+		args = append(args, val)
+		values[i] = bindVar(dbType, len(args))
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteIdent(dbType, table), strings.Join(names, ", "), strings.Join(values, ", "))
+
+	return query, args, nil
+}
@@ -1,8 +1,11 @@
 package internal
 
 import (
-	"database/sql"
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 )
 
@@ -17,6 +20,7 @@ const (
 // Hook represents a setup or teardown action
 type Hook struct {
 	SQL  string        `yaml:"sql,omitempty"`  // SQL query to execute
+	Args []any         `yaml:"args,omitempty"` // Bind parameters for $1/? in the SQL query
 	HTTP *HTTPHookSpec `yaml:"http,omitempty"` // HTTP request to make
 	Name string        `yaml:"name,omitempty"` // Optional name for logging
 }
@@ -31,18 +35,37 @@ type HTTPHookSpec struct {
 
 // HookExecutor executes setup and teardown hooks
 type HookExecutor struct {
-	db      *sql.DB
+	db      Database
 	baseURL string
+	client  *http.Client
+	opts    RenderOptions
 }
 
 // NewHookExecutor creates a new hook executor
-func NewHookExecutor(db *sql.DB, baseURL string) *HookExecutor {
+func NewHookExecutor(db Database, baseURL string) *HookExecutor {
 	return &HookExecutor{
 		db:      db,
 		baseURL: baseURL,
+		client:  &http.Client{},
 	}
 }
 
+// WithHTTPClient sets the client used for HTTP hooks
+func (e *HookExecutor) WithHTTPClient(client *http.Client) *HookExecutor {
+	if client != nil {
+		e.client = client
+	}
+
+	return e
+}
+
+// WithRenderOptions sets how placeholders in hooks are rendered
+func (e *HookExecutor) WithRenderOptions(opts RenderOptions) *HookExecutor {
+	e.opts = opts
+
+	return e
+}
+
 // ExecuteHooks executes a list of hooks
 func (e *HookExecutor) ExecuteHooks(hooks []Hook, hookType HookType, ctx map[string]any) error {
 	for i, hook := range hooks {
@@ -63,7 +86,7 @@ func (e *HookExecutor) ExecuteHooks(hooks []Hook, hookType HookType, ctx map[str
 func (e *HookExecutor) executeHook(hook Hook, name string, ctx map[string]any) error {
 	// SQL hook
 	if hook.SQL != "" {
-		return e.executeSQLHook(hook.SQL, ctx)
+		return e.executeSQLHook(hook.SQL, hook.Args, ctx)
 	}
 
 	// HTTP hook
@@ -75,15 +98,23 @@ func (e *HookExecutor) executeHook(hook Hook, name string, ctx map[string]any) e
 }
 
 // executeSQLHook executes a SQL hook
-func (e *HookExecutor) executeSQLHook(query string, ctx map[string]any) error {
+func (e *HookExecutor) executeSQLHook(query string, args []any, ctx map[string]any) error {
 	if e.db == nil {
 		return fmt.Errorf("database connection not available for SQL hook")
 	}
 
 	// Render the query with context
-	renderedQuery := RenderTemplate(query, ctx)
+	renderedQuery, err := RenderTemplateWithOptions(query, ctx, e.opts)
+	if err != nil {
+		return fmt.Errorf("failed to render SQL: %w", err)
+	}
 
-	_, err := e.db.Exec(renderedQuery)
+	renderedArgs, err := renderArgs(args, ctx, e.opts)
+	if err != nil {
+		return fmt.Errorf("failed to render SQL args: %w", err)
+	}
+
+	err = e.db.Exec(context.Background(), renderedQuery, renderedArgs...)
 	if err != nil {
 		return fmt.Errorf("SQL execution failed: %w", err)
 	}
@@ -94,23 +125,45 @@ func (e *HookExecutor) executeSQLHook(query string, ctx map[string]any) error {
 // executeHTTPHook executes an HTTP hook
 func (e *HookExecutor) executeHTTPHook(spec *HTTPHookSpec, ctx map[string]any) error {
 	// Render the request
-	renderedPath := RenderTemplate(spec.Path, ctx)
+	renderedPath, err := RenderTemplateWithOptions(spec.Path, ctx, e.opts)
+	if err != nil {
+		return fmt.Errorf("failed to render path: %w", err)
+	}
+
+	var body io.Reader
+	if spec.Body != nil {
+		renderedBody, err := renderValue(spec.Body, ctx, e.opts)
+		if err != nil {
+			return fmt.Errorf("failed to render body: %w", err)
+		}
+
+		b, err := json.Marshal(renderedBody)
+		if err != nil {
+			return fmt.Errorf("failed to marshal body: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
 
 	// Create HTTP request
-	req, err := http.NewRequest(spec.Method, e.baseURL+renderedPath, nil)
+	req, err := http.NewRequest(spec.Method, e.baseURL+renderedPath, body)
 	if err != nil {
 		return fmt.Errorf("failed to create HTTP request: %w", err)
 	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
 	// Add headers
-	for k, v := range spec.Headers {
-		renderedValue := RenderTemplate(v, ctx)
+	for _, k := range sortedKeys(spec.Headers) {
+		renderedValue, err := RenderTemplateWithOptions(spec.Headers[k], ctx, e.opts)
+		if err != nil {
+			return fmt.Errorf("failed to render header %s: %w", k, err)
+		}
 		req.Header.Set(k, renderedValue)
 	}
 
 	// Execute request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := e.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHookExecutor_SQLArgs(t *testing.T) {
+	db := &recordingDB{}
+	executor := NewHookExecutor(db, "")
+
+	hooks := []Hook{{
+		SQL:  "INSERT INTO notes (user_id, body) VALUES ($1, $2)",
+		Args: []any{"{{user.id}}", "it's {{word}}"},
+	}}
+	ctx := map[string]any{
+		"user": map[string]any{"id": float64(42)},
+		"word": "fine",
+	}
+
+	if err := executor.ExecuteHooks(hooks, HookTypeSetup, ctx); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if len(db.execs) != 1 || db.execs[0] != hooks[0].SQL {
+		t.Fatalf("Expected the query to be executed unchanged, got %v", db.execs)
+	}
+
+	expected := []any{float64(42), "it's fine"}
+	if !reflect.DeepEqual(db.args[0], expected) {
+		t.Errorf("Expected args %#v, got %#v", expected, db.args[0])
+	}
+}
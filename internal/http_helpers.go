@@ -16,11 +16,18 @@ import (
 // ExecuteRequest performs an HTTP request with full rendering support
 func ExecuteRequest(t *testing.T, step Step, handler http.Handler, ctxMap map[string]any) *httptest.ResponseRecorder {
 	t.Helper()
-	const op = "ExecuteRequest"
 
 	// Render request with context and faker
 	step.Request = renderRequest(step.Request, ctxMap)
 
+	return executeRenderedRequest(t, step, handler)
+}
+
+// executeRenderedRequest performs an HTTP request whose spec is already rendered
+func executeRenderedRequest(t *testing.T, step Step, handler http.Handler) *httptest.ResponseRecorder {
+	t.Helper()
+	const op = "ExecuteRequest"
+
 	var body io.Reader
 	if step.Request.BodyFile != "" {
 		bodyData, err := os.ReadFile(step.Request.BodyFile)
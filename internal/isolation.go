@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// postgresAdminDB is the maintenance database used to create and drop clones
+const postgresAdminDB = "postgres"
+
+// IsolationMode selects how the database changes of a case are undone
+type IsolationMode string
+
+const (
+	// IsolationNone leaves the changes of a case in place
+	IsolationNone IsolationMode = "none"
+	// IsolationTransaction runs the case in a transaction that is rolled back
+	IsolationTransaction IsolationMode = "transaction"
+	// IsolationSnapshot restores a PostgreSQL snapshot after the case
+	IsolationSnapshot IsolationMode = "snapshot"
+)
+
+// ParseIsolationMode parses an isolation mode name as used in YAML cases.
+// An empty name means IsolationNone
+func ParseIsolationMode(s string) (IsolationMode, error) {
+	switch mode := IsolationMode(s); mode {
+	case "", IsolationNone:
+		return IsolationNone, nil
+	case IsolationTransaction, IsolationSnapshot:
+		return mode, nil
+	default:
+		return IsolationNone, fmt.Errorf("unknown isolation mode: %s (expected none, transaction or snapshot)", s)
+	}
+}
+
+var dbNameKVRe = regexp.MustCompile(`(^|\s)dbname=('[^']*'|\S*)`)
+
+// PostgresDBName returns the database name referenced by a PostgreSQL
+// connection string in URL or key=value form
+func PostgresDBName(connStr string) (string, error) {
+	if isPostgresURL(connStr) {
+		u, err := url.Parse(connStr)
+		if err != nil {
+			return "", fmt.Errorf("invalid connection string: %w", err)
+		}
+
+		return strings.TrimPrefix(u.Path, "/"), nil
+	}
+
+	m := dbNameKVRe.FindStringSubmatch(connStr)
+	if m == nil {
+		return "", fmt.Errorf("connection string has no dbname")
+	}
+
+	return strings.Trim(m[2], "'"), nil
+}
+
+// WithPostgresDBName returns a copy of the connection string pointing to another database
+func WithPostgresDBName(connStr, dbName string) (string, error) {
+	if isPostgresURL(connStr) {
+		u, err := url.Parse(connStr)
+		if err != nil {
+			return "", fmt.Errorf("invalid connection string: %w", err)
+		}
+		u.Path = "/" + dbName
+
+		return u.String(), nil
+	}
+
+	if dbNameKVRe.MatchString(connStr) {
+		return dbNameKVRe.ReplaceAllString(connStr, "${1}dbname="+dbName), nil
+	}
+
+	return strings.TrimSpace(connStr + " dbname=" + dbName), nil
+}
+
+// ClonePostgresDatabase creates a new database using the one referenced by
+// connStr as a template and returns the connection string of the clone.
+// The template database must have no active connections
+func ClonePostgresDatabase(ctx context.Context, connStr, cloneName string) (string, error) {
+	const op = "ClonePostgresDatabase"
+
+	srcName, err := PostgresDBName(connStr)
+	if err != nil {
+		return "", NewError(ErrInvalidInput, op, "failed to parse connection string").
+			WithContext("error", err.Error())
+	}
+
+	err = execAdmin(ctx, connStr,
+		"DROP DATABASE IF EXISTS "+pq.QuoteIdentifier(cloneName),
+		"CREATE DATABASE "+pq.QuoteIdentifier(cloneName)+" TEMPLATE "+pq.QuoteIdentifier(srcName),
+	)
+	if err != nil {
+		return "", NewError(ErrDatabase, op, "failed to clone database").
+			WithContext("source", srcName).
+			WithContext("clone", cloneName).
+			WithContext("error", err.Error())
+	}
+
+	return WithPostgresDBName(connStr, cloneName)
+}
+
+// DropPostgresDatabase drops a database created by ClonePostgresDatabase,
+// terminating connections that are still open
+func DropPostgresDatabase(ctx context.Context, connStr, name string) error {
+	const op = "DropPostgresDatabase"
+
+	err := execAdminExclusive(ctx, connStr, name, "DROP DATABASE IF EXISTS "+pq.QuoteIdentifier(name))
+	if err != nil {
+		return NewError(ErrDatabase, op, "failed to drop database").
+			WithContext("database", name).
+			WithContext("error", err.Error())
+	}
+
+	return nil
+}
+
+// SnapshotPostgresDatabase copies the database referenced by connStr to
+// snapshotName. Open connections to the database are terminated, so
+// connection pools of the application must be able to reconnect; the copy is
+// retried while they do
+func SnapshotPostgresDatabase(ctx context.Context, connStr, snapshotName string) error {
+	const op = "SnapshotPostgresDatabase"
+
+	srcName, err := PostgresDBName(connStr)
+	if err != nil {
+		return NewError(ErrInvalidInput, op, "failed to parse connection string").
+			WithContext("error", err.Error())
+	}
+
+	err = execAdmin(ctx, connStr, "DROP DATABASE IF EXISTS "+pq.QuoteIdentifier(snapshotName))
+	if err == nil {
+		err = execAdminExclusive(ctx, connStr, srcName,
+			"CREATE DATABASE "+pq.QuoteIdentifier(snapshotName)+" TEMPLATE "+pq.QuoteIdentifier(srcName))
+	}
+	if err != nil {
+		return NewError(ErrDatabase, op, "failed to snapshot database").
+			WithContext("source", srcName).
+			WithContext("snapshot", snapshotName).
+			WithContext("error", err.Error())
+	}
+
+	return nil
+}
+
+// RestorePostgresDatabase recreates the database referenced by connStr from
+// a snapshot made by SnapshotPostgresDatabase
+func RestorePostgresDatabase(ctx context.Context, connStr, snapshotName string) error {
+	const op = "RestorePostgresDatabase"
+
+	name, err := PostgresDBName(connStr)
+	if err != nil {
+		return NewError(ErrInvalidInput, op, "failed to parse connection string").
+			WithContext("error", err.Error())
+	}
+
+	err = execAdminExclusive(ctx, connStr, name, "DROP DATABASE IF EXISTS "+pq.QuoteIdentifier(name))
+	if err == nil {
+		err = execAdmin(ctx, connStr,
+			"CREATE DATABASE "+pq.QuoteIdentifier(name)+" TEMPLATE "+pq.QuoteIdentifier(snapshotName))
+	}
+	if err != nil {
+		return NewError(ErrDatabase, op, "failed to restore database").
+			WithContext("database", name).
+			WithContext("snapshot", snapshotName).
+			WithContext("error", err.Error())
+	}
+
+	return nil
+}
+
+// terminateConnections returns the statement closing other sessions on a database
+func terminateConnections(name string) string {
+	return "SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = " + pq.QuoteLiteral(name) +
+		" AND pid <> pg_backend_pid()"
+}
+
+// execAdmin runs statements against the maintenance database of the server
+func execAdmin(ctx context.Context, connStr string, queries ...string) error {
+	db, err := openAdmin(connStr)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, query := range queries {
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// execAdminExclusive runs a statement that needs the database name to have
+// no other sessions, like DROP DATABASE or CREATE DATABASE ... TEMPLATE
+func execAdminExclusive(ctx context.Context, connStr, name, query string) error {
+	db, err := openAdmin(connStr)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return execExclusive(ctx, db, name, query, exclusiveAttempts, exclusiveRetryDelay)
+}
+
+const (
+	exclusiveAttempts   = 20
+	exclusiveRetryDelay = 50 * time.Millisecond
+)
+
+// execExclusive terminates the sessions on a database right before query.
+// An application pool may reconnect in between, so the pair is retried while
+// the database is reported as being accessed by other users
+func execExclusive(ctx context.Context, conn sqlConn, name, query string, attempts int, delay time.Duration) error {
+	for attempt := 1; ; attempt++ {
+		if _, err := conn.ExecContext(ctx, terminateConnections(name)); err != nil {
+			return err
+		}
+
+		_, err := conn.ExecContext(ctx, query)
+		if err == nil || !isObjectInUse(err) || attempt >= attempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// isObjectInUse reports a PostgreSQL object_in_use error (55006)
+func isObjectInUse(err error) bool {
+	var pqErr *pq.Error
+
+	return errors.As(err, &pqErr) && pqErr.Code == "55006"
+}
+
+// openAdmin opens the maintenance database of the server
+func openAdmin(connStr string) (*sql.DB, error) {
+	adminConnStr, err := WithPostgresDBName(connStr, postgresAdminDB)
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.Open("postgres", adminConnStr)
+}
+
+func isPostgresURL(connStr string) bool {
+	return strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://")
+}
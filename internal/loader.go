@@ -39,6 +39,11 @@ func LoadTestCases(dir string) ([]TestCase, error) {
 				WithContext("error", err.Error())
 		}
 
+		for i := range tcs {
+			tcs[i].File = file
+			tcs[i].Index = len(all) + i
+		}
+
 		all = append(all, tcs...)
 	}
 
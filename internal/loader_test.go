@@ -95,13 +95,16 @@ func TestLoadTestCases(t *testing.T) {
 		if cases[0].Steps[0].Request.Method != "GET" {
 			t.Errorf("Expected method 'GET', got '%s'", cases[0].Steps[0].Request.Method)
 		}
+		if cases[0].File != filepath.Join(tempDir, "valid1.yml") {
+			t.Errorf("Expected file to be recorded, got '%s'", cases[0].File)
+		}
 	}
 
 	if len(cases) > 1 {
 		if cases[1].Name != "Test Case 2" {
 			t.Errorf("Expected name 'Test Case 2', got '%s'", cases[1].Name)
 		}
-		if len(cases[1].Fixtures) != 1 || cases[1].Fixtures[0] != "users" {
+		if len(cases[1].Fixtures) != 1 || cases[1].Fixtures[0].File != "users" {
 			t.Errorf("Expected fixtures ['users'], got %v", cases[1].Fixtures)
 		}
 		if len(cases[1].Steps[0].DBChecks) != 1 {
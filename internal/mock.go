@@ -1,9 +1,31 @@
 package internal
 
+import "sync"
+
 type MockRoute struct {
 	Method   string       `yaml:"method"`
 	Path     string       `yaml:"path"`
+	Match    *MockMatch   `yaml:"match,omitempty"` // Request conditions; routes without match are the fallback
 	Response MockResponse `yaml:"response"`
+
+	Responses []MockResponse    `yaml:"responses,omitempty"` // Returned one per call instead of Response
+	Policy    string            `yaml:"policy,omitempty"`    // What follows the last of Responses: thenLast (default) or repeat
+	SetState  map[string]string `yaml:"setState,omitempty"`  // Named states set when the route answers, see MockMatch.State
+}
+
+const (
+	MockPolicyThenLast = "thenLast"
+	MockPolicyRepeat   = "repeat"
+)
+
+// MockMatch selects a route by request content. Each value is either
+// compared as a string or is an {operator, value} assertion, e.g.
+// {operator: matches, value: "^4111"}
+type MockMatch struct {
+	Headers map[string]any `yaml:"headers,omitempty"`
+	Query   map[string]any `yaml:"query,omitempty"`
+	Body    map[string]any `yaml:"body,omitempty"`  // JSON path to expected value
+	State   map[string]any `yaml:"state,omitempty"` // Named states of the mock server; unset states are null
 }
 
 type MockResponse struct {
@@ -24,10 +46,42 @@ type MockCall struct {
 	Body    string
 }
 
+// SpyStore records the calls made to a mock server. It is safe for
+// concurrent use; reads return snapshots
 type SpyStore struct {
-	Calls *[]MockCall
+	mu    sync.Mutex
+	calls []MockCall
 }
 
+// Add records a call
 func (s *SpyStore) Add(call MockCall) {
-	*s.Calls = append(*s.Calls, call)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, call)
+}
+
+// Calls returns a copy of the recorded calls
+func (s *SpyStore) Calls() []MockCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calls := make([]MockCall, len(s.calls))
+	for i, call := range s.calls {
+		calls[i] = call
+		calls[i].Headers = make(map[string]string, len(call.Headers))
+		for k, v := range call.Headers {
+			calls[i].Headers[k] = v
+		}
+	}
+
+	return calls
+}
+
+// Len returns the number of recorded calls
+func (s *SpyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.calls)
 }
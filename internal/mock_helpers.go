@@ -10,10 +10,34 @@ func AssertMockCalls(t *testing.T, checks []MockCallCheck, mocks []*MockInstance
 	t.Helper()
 	const op = "AssertMockCalls"
 
+	assertMockCalls(t, op, checks, mocks, nil)
+}
+
+// AssertStepMockCalls checks the calls made to mocks since the call counts
+// in since were taken
+func AssertStepMockCalls(t *testing.T, checks []MockCallCheck, mocks []*MockInstance, since map[string]int) {
+	t.Helper()
+	const op = "AssertStepMockCalls"
+
+	assertMockCalls(t, op, checks, mocks, since)
+}
+
+// MockCallCounts returns the number of calls recorded by every mock
+func MockCallCounts(mocks []*MockInstance) map[string]int {
+	counts := make(map[string]int, len(mocks))
+	for _, inst := range mocks {
+		counts[inst.name] = inst.router.Spy().Len()
+	}
+
+	return counts
+}
+
+func assertMockCalls(t *testing.T, op string, checks []MockCallCheck, mocks []*MockInstance, since map[string]int) {
+	t.Helper()
+
 	for _, check := range checks {
-		calls := GetMockCalls(mocks, check.Mock)
-		if calls == nil {
-			mockErr := NewError(ErrMock, op, "mock not found or has no calls").
+		if FindMockInstance(mocks, check.Mock) == nil {
+			mockErr := NewError(ErrMock, op, "mock not found").
 				WithContext("mock", check.Mock)
 			// Using Errorf instead of Fatalf to allow tests to continue
 			t.Errorf("%+v", mockErr)
@@ -21,6 +45,11 @@ func AssertMockCalls(t *testing.T, checks []MockCallCheck, mocks []*MockInstance
 			continue
 		}
 
+		calls := GetMockCalls(mocks, check.Mock)
+		if offset := since[check.Mock]; offset <= len(calls) {
+			calls = calls[offset:]
+		}
+
 		matched := 0
 		for _, call := range calls {
 			if check.Expect.Method != "" && call.Method != check.Expect.Method {
@@ -46,17 +75,52 @@ func AssertMockCalls(t *testing.T, checks []MockCallCheck, mocks []*MockInstance
 	}
 }
 
-// GetMockCalls returns all calls made to a mock
+// GetMockCalls returns a copy of the calls made to a mock
 func GetMockCalls(mocks []*MockInstance, name string) []MockCall {
 	for _, inst := range mocks {
 		if inst.name == name {
-			return *inst.router.spy.Calls
+			return inst.router.Spy().Calls()
 		}
 	}
 
 	return nil
 }
 
+// refreshMockCalls stores a snapshot of the calls of the case mocks in the
+// context as a list of {method, path, headers, body} objects
+func refreshMockCalls(ctx map[string]any, mocks []*MockInstance) {
+	for _, inst := range mocks {
+		key := inst.name + ".calls"
+		if _, ok := ctx[key]; !ok {
+			continue
+		}
+
+		calls := inst.router.Spy().Calls()
+		list := make([]any, len(calls))
+		for i, call := range calls {
+			headers := make(map[string]any, len(call.Headers))
+			for k, v := range call.Headers {
+				headers[k] = v
+			}
+			list[i] = map[string]any{
+				"method":  call.Method,
+				"path":    call.Path,
+				"headers": headers,
+				"body":    call.Body,
+			}
+		}
+		ctx[key] = list
+	}
+}
+
+// setMockRenderContext passes the scenario context to the response
+// templates of the mocks
+func setMockRenderContext(mocks []*MockInstance, ctx map[string]any, opts RenderOptions) {
+	for _, inst := range mocks {
+		inst.router.SetRenderContext(ctx, opts)
+	}
+}
+
 // FindMockInstance returns a mock instance by name
 func FindMockInstance(mocks []*MockInstance, name string) *MockInstance {
 	for _, inst := range mocks {
@@ -72,7 +72,8 @@ func TestGetMockCalls(t *testing.T) {
 		Body:    `{"name":"John"}`,
 	}
 
-	*router1.spy.Calls = append(*router1.spy.Calls, call1, call2)
+	router1.Spy().Add(call1)
+	router1.Spy().Add(call2)
 
 	// Add calls to mock2
 	call3 := MockCall{
@@ -82,7 +83,7 @@ func TestGetMockCalls(t *testing.T) {
 		Body:    "",
 	}
 
-	*router2.spy.Calls = append(*router2.spy.Calls, call3)
+	router2.Spy().Add(call3)
 
 	mocks := []*MockInstance{mock1, mock2}
 
@@ -142,7 +143,9 @@ func TestAssertMockCalls_SuccessfulAssertion(t *testing.T) {
 		Body:    `{"name":"Jane"}`,
 	}
 
-	*router1.spy.Calls = append(*router1.spy.Calls, call1, call2, call3)
+	router1.Spy().Add(call1)
+	router1.Spy().Add(call2)
+	router1.Spy().Add(call3)
 
 	mocks := []*MockInstance{mock1}
 
@@ -184,7 +187,7 @@ func TestAssertMockCalls_BodyContains(t *testing.T) {
 		Body:    `{"name":"John"}`,
 	}
 
-	*router1.spy.Calls = append(*router1.spy.Calls, call1)
+	router1.Spy().Add(call1)
 
 	mocks := []*MockInstance{mock1}
 
@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// mockRequest is the part of a request that route conditions look at
+type mockRequest struct {
+	headers http.Header
+	query   map[string][]string
+	body    []byte
+	state   map[string]string
+
+	json    any
+	decoded bool
+	jsonErr error
+}
+
+// bodyJSON decodes the request body once
+func (r *mockRequest) bodyJSON() (any, error) {
+	if !r.decoded {
+		r.decoded = true
+		r.jsonErr = json.Unmarshal(r.body, &r.json)
+	}
+
+	return r.json, r.jsonErr
+}
+
+// selectMockRoute returns the first route whose conditions all hold, or nil.
+// Routes without conditions are the fallback and are tried last, wherever
+// they are declared
+func selectMockRoute(routes []*mockRouteEntry, req *mockRequest) (*mockRouteEntry, error) {
+	for _, entry := range routes {
+		if entry.Match.isEmpty() {
+			continue
+		}
+
+		ok, err := entry.Match.matches(req)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", entry.Method, entry.Path, err)
+		}
+		if ok {
+			return entry, nil
+		}
+	}
+
+	for _, entry := range routes {
+		if entry.Match.isEmpty() {
+			return entry, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// isEmpty reports whether the match has no conditions
+func (m *MockMatch) isEmpty() bool {
+	return m == nil || len(m.Headers)+len(m.Query)+len(m.Body)+len(m.State) == 0
+}
+
+// matches reports whether the request satisfies every condition. A nil
+// match accepts any request
+func (m *MockMatch) matches(req *mockRequest) (bool, error) {
+	if m == nil {
+		return true, nil
+	}
+
+	for _, name := range sortedKeys(m.Headers) {
+		var actual any
+		if values := req.headers.Values(name); len(values) > 0 {
+			actual = values[0]
+		}
+		if ok, err := matchCondition(actual, m.Headers[name]); !ok || err != nil {
+			return false, wrapConditionErr("header "+name, err)
+		}
+	}
+
+	for _, name := range sortedKeys(m.Query) {
+		var actual any
+		if values := req.query[name]; len(values) > 0 {
+			actual = values[0]
+		}
+		if ok, err := matchCondition(actual, m.Query[name]); !ok || err != nil {
+			return false, wrapConditionErr("query "+name, err)
+		}
+	}
+
+	for _, name := range sortedKeys(m.State) {
+		var actual any
+		if value, ok := req.state[name]; ok {
+			actual = value
+		}
+		if ok, err := matchCondition(actual, m.State[name]); !ok || err != nil {
+			return false, wrapConditionErr("state "+name, err)
+		}
+	}
+
+	if len(m.Body) > 0 {
+		data, err := req.bodyJSON()
+		if err != nil {
+			// Body conditions never match a non-JSON body
+			return false, nil
+		}
+
+		for _, path := range sortedKeys(m.Body) {
+			actual, err := ParseJSONPath(path, data)
+			if err != nil {
+				actual = nil
+			}
+			if ok, err := matchCondition(actual, m.Body[path]); !ok || err != nil {
+				return false, wrapConditionErr("body "+path, err)
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// matchCondition compares a request value with an expected value or an
+// {operator, value} assertion. Missing values are nil
+func matchCondition(actual, expected any) (bool, error) {
+	if op, value, ok := columnOperator(expected); ok {
+		matched, err := evaluateAssertion(actual, op, value)
+		if err != nil && actual == nil {
+			// A missing value fails numeric operators without an error
+			return false, nil
+		}
+
+		return matched, err
+	}
+	if actual == nil {
+		return expected == nil, nil
+	}
+
+	return assertEquals(actual, expected), nil
+}
+
+// wrapConditionErr names the condition an evaluation error came from
+func wrapConditionErr(field string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %w", field, err)
+}
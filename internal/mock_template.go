@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// mockTemplateContext is the context of a response template: the scenario
+// values plus the incoming request as request.method, request.path.<param>,
+// request.query.<name>, request.headers.<Name> and request.body
+func mockTemplateContext(vars map[string]any, r *http.Request, params httprouter.Params, body []byte) map[string]any {
+	ctx := make(map[string]any, len(vars)+16)
+	maps.Copy(ctx, vars)
+
+	path := make(map[string]any, len(params))
+	for _, p := range params {
+		path[p.Key] = p.Value
+	}
+
+	query := make(map[string]any)
+	for k, v := range r.URL.Query() {
+		query[k] = v[0]
+	}
+
+	headers := make(map[string]any, len(r.Header))
+	for k, v := range r.Header {
+		headers[k] = strings.Join(v, ", ")
+	}
+
+	var reqBody any = string(body)
+	var data any
+	if err := json.Unmarshal(body, &data); err == nil {
+		reqBody = data
+	}
+
+	request := map[string]any{
+		"method":  r.Method,
+		"path":    path,
+		"query":   query,
+		"headers": headers,
+		"body":    reqBody,
+	}
+	ctx["request"] = request
+	// Flattened keys resolve names an expression cannot, e.g. X-Request-Id
+	extractJSONFields("request", request, ctx)
+
+	return ctx
+}
+
+// renderMockResponse renders the placeholders of a response
+func renderMockResponse(resp MockResponse, ctx map[string]any, opts RenderOptions) (MockResponse, error) {
+	rendered := resp
+
+	if len(resp.Headers) > 0 {
+		rendered.Headers = make(map[string]string, len(resp.Headers))
+		for _, k := range sortedKeys(resp.Headers) {
+			value, err := RenderTemplateWithOptions(resp.Headers[k], ctx, opts)
+			if err != nil {
+				return MockResponse{}, fmt.Errorf("header %s: %w", k, err)
+			}
+			rendered.Headers[k] = value
+		}
+	}
+
+	var err error
+	if rendered.JSON, err = renderJSONTemplate(resp.JSON, ctx, opts); err != nil {
+		return MockResponse{}, fmt.Errorf("json: %w", err)
+	}
+	if rendered.Body, err = RenderTemplateWithOptions(resp.Body, ctx, opts); err != nil {
+		return MockResponse{}, fmt.Errorf("body: %w", err)
+	}
+
+	return rendered, nil
+}
+
+// hasPlaceholders reports whether a response needs rendering
+func hasPlaceholders(resp MockResponse) bool {
+	if strings.Contains(resp.JSON, "{{") || strings.Contains(resp.Body, "{{") {
+		return true
+	}
+	for _, v := range resp.Headers {
+		if strings.Contains(v, "{{") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RemoteHandler is an http.Handler that forwards every request to a live
+// base URL, so the runner can drive a deployed service the same way it
+// drives an in-process handler
+type RemoteHandler struct {
+	baseURL string
+	client  *http.Client
+}
+
+// DefaultRemoteTimeout bounds a request of the default remote client
+const DefaultRemoteTimeout = 30 * time.Second
+
+// NewRemoteHandler creates a handler forwarding requests to baseURL.
+// A nil client falls back to NewRemoteClient
+func NewRemoteHandler(baseURL string, client *http.Client) *RemoteHandler {
+	if client == nil {
+		client = NewRemoteClient()
+	}
+
+	return &RemoteHandler{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  client,
+	}
+}
+
+// NewRemoteClient returns the default client of remote mode. Like an
+// in-process handler it does not follow redirects, so 3xx responses and
+// their Location header reach the assertions, and it gives up on a server
+// that hangs after DefaultRemoteTimeout
+func NewRemoteClient() *http.Client {
+	return &http.Client{
+		Timeout: DefaultRemoteTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// BaseURL returns the target base URL without a trailing slash
+func (h *RemoteHandler) BaseURL() string {
+	return h.baseURL
+}
+
+// Client returns the HTTP client used for outgoing requests
+func (h *RemoteHandler) Client() *http.Client {
+	return h.client
+}
+
+func (h *RemoteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "RemoteHandler.ServeHTTP"
+
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, h.baseURL+r.URL.RequestURI(), r.Body)
+	if err != nil {
+		writeTransportError(w, NewError(ErrHTTP, op, "failed to create remote request").
+			WithContext("error", err.Error()))
+
+		return
+	}
+	req.Header = r.Header.Clone()
+	req.ContentLength = r.ContentLength
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		writeTransportError(w, NewError(ErrHTTP, op, "remote request failed").
+			WithContext("url", req.URL.String()).
+			WithContext("error", err.Error()))
+
+		return
+	}
+	defer resp.Body.Close()
+
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// writeTransportError reports a transport failure as 502 so that retry
+// and status assertions see it like any other failed response
+func writeTransportError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusBadGateway)
+	_, _ = fmt.Fprint(w, err.Error())
+}
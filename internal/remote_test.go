@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRemoteHandler(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Echo-Token", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.RequestURI() + `","body":` + string(body) + `}`))
+	}))
+	defer srv.Close()
+
+	handler := NewRemoteHandler(srv.URL+"/", nil)
+	if handler.BaseURL() != srv.URL {
+		t.Errorf("Expected trailing slash to be trimmed, got %q", handler.BaseURL())
+	}
+
+	step := Step{
+		Name: "create",
+		Request: RequestSpec{
+			Method:  "POST",
+			Path:    "/items?page={{page}}",
+			Headers: map[string]string{"Authorization": "Bearer {{token}}"},
+			Body:    map[string]any{"name": "item"},
+		},
+	}
+	ctx := map[string]any{"page": 2, "token": "secret"}
+
+	rec := ExecuteRequest(t, step, handler, ctx)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-Echo-Token"); got != "Bearer secret" {
+		t.Errorf("Expected forwarded Authorization header, got %q", got)
+	}
+	if got := rec.Body.String(); got != `{"path":"/items?page=2","body":{"name":"item"}}` {
+		t.Errorf("Unexpected body: %s", got)
+	}
+}
+
+func TestRemoteHandler_TransportError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	handler := NewRemoteHandler(url, &http.Client{})
+	step := Step{
+		Name:    "unreachable",
+		Request: RequestSpec{Method: "GET", Path: "/health"},
+	}
+
+	rec := ExecuteRequest(t, step, handler, map[string]any{})
+
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "remote request failed") {
+		t.Errorf("Expected transport error in body, got %q", rec.Body.String())
+	}
+}
+
+func TestNewRemoteHandler_DefaultClient(t *testing.T) {
+	client := NewRemoteHandler("http://localhost", nil).Client()
+
+	if client == http.DefaultClient {
+		t.Fatal("Expected a dedicated client, got http.DefaultClient")
+	}
+	if client.Timeout != DefaultRemoteTimeout {
+		t.Errorf("Expected timeout %v, got %v", DefaultRemoteTimeout, client.Timeout)
+	}
+	if client.CheckRedirect == nil || client.CheckRedirect(nil, nil) != http.ErrUseLastResponse {
+		t.Error("Expected redirects not to be followed")
+	}
+}
@@ -1,85 +1,232 @@
 package internal
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
-	"strconv"
+	"sort"
+	"strings"
 )
 
-var placeholderRe = regexp.MustCompile(`\{\{([a-zA-Z0-9\.\[\]_-]+)\}\}`)
+// placeholderKey is the set of characters allowed in placeholder keys.
+// '#' names a generated faker value: {{faker.email#alice}}, and generators
+// take arguments in parentheses: {{faker.int(1,100)#n}}
+const placeholderKey = `[a-zA-Z0-9\.\[\]_#-]+(?:\([^(){}]*\)(?:#[a-zA-Z0-9_-]+)?)?`
+
+// placeholderExpr is the content of a placeholder: a key or an expression
+// such as {{ page + 1 }} or {{ name | upper }}
+const placeholderExpr = `\s*([^{}]+?)\s*`
+
+var placeholderRe = regexp.MustCompile(`\{\{` + placeholderExpr + `\}\}`)
+
+// quotedPlaceholderRe matches a JSON string consisting of a single placeholder
+var quotedPlaceholderRe = regexp.MustCompile(`"\{\{` + placeholderExpr + `\}\}"`)
 
 func RenderTemplate(input string, ctx map[string]any) string {
-	return placeholderRe.ReplaceAllStringFunc(input, func(match string) string {
-		key := placeholderRe.FindStringSubmatch(match)[1]
-		if val, ok := ctx[key]; ok {
-			return fmt.Sprintf("%+v", val)
-		}
+	rendered, _ := RenderTemplateWithOptions(input, ctx, DefaultRenderOptions())
 
-		return match
-	})
+	return rendered
 }
 
-// tryConvertToNumber attempts to convert a string to a number if possible
-func tryConvertToNumber(s string) any {
-	// Try integer first
-	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
-		return i
-	}
-	// Try float
-	if f, err := strconv.ParseFloat(s, 64); err == nil {
-		return f
+// resolvePlaceholder looks a placeholder key up in the context, falling back
+// to faker generators for faker.* keys
+func resolvePlaceholder(key string, ctx map[string]any, opts RenderOptions) (any, bool) {
+	if val, ok := ctx[key]; ok {
+		return val, true
 	}
-	// Try boolean
-	if b, err := strconv.ParseBool(s); err == nil {
-		return b
+
+	if strings.HasPrefix(key, fakerPrefix) {
+		return opts.faker().resolve(key, ctx, opts.rand())
 	}
-	return s
+
+	return nil, false
 }
 
 func RenderAny(v any, ctx map[string]any) any {
+	rendered, _ := renderValue(v, ctx, DefaultRenderOptions())
+
+	return rendered
+}
+
+// renderValue renders v like RenderAny and reports every unresolved
+// placeholder when opts is strict
+func renderValue(v any, ctx map[string]any, opts RenderOptions) (any, error) {
 	switch val := v.(type) {
 	case string:
-		rendered := RenderTemplate(val, ctx)
-		// If the original string was a placeholder and it got replaced,
-		// try to convert the result to a number if it looks like one
-		if placeholderRe.MatchString(val) && rendered != val {
-			// The placeholder was replaced, try to convert to number
-			return tryConvertToNumber(rendered)
+		// A value that is exactly one placeholder is replaced by the typed
+		// context value: objects, arrays, numbers and strings keep their type.
+		// Use a cast such as {{id | string}} or {{id | int}} to coerce
+		if m := placeholderRe.FindStringSubmatch(val); m != nil && m[0] == val {
+			if typed, err := evalPlaceholder(m[1], ctx, opts); err == nil {
+				return typed, nil
+			}
 		}
-		return rendered
+
+		return RenderTemplateWithOptions(val, ctx, opts)
 	case map[string]any:
 		out := make(map[string]any, len(val))
-		for k, v2 := range val {
-			out[k] = RenderAny(v2, ctx)
+		var errs []error
+		// Sorted so that faker values are drawn in a reproducible order
+		for _, k := range sortedKeys(val) {
+			rendered, err := renderValue(val[k], ctx, opts)
+			out[k] = rendered
+			errs = append(errs, err)
 		}
 
-		return out
+		return out, mergeRenderErrors(errs...)
 	case []any:
 		out := make([]any, len(val))
+		var errs []error
 		for i, v2 := range val {
-			out[i] = RenderAny(v2, ctx)
+			rendered, err := renderValue(v2, ctx, opts)
+			out[i] = rendered
+			errs = append(errs, err)
 		}
 
-		return out
+		return out, mergeRenderErrors(errs...)
 	default:
-		return val
+		return val, nil
 	}
 }
 
 func renderRequest(req RequestSpec, ctx map[string]any) RequestSpec {
-	req.Path = RenderTemplate(req.Path, ctx)
-	req.Body = RenderAny(req.Body, ctx)
+	rendered, _ := renderRequestWithOptions(req, ctx, DefaultRenderOptions())
+
+	return rendered
+}
 
-	for k, v := range req.Headers {
-		req.Headers[k] = RenderTemplate(v, ctx)
+// renderRequestWithOptions renders the request and reports every
+// unresolved placeholder when opts is strict
+func renderRequestWithOptions(req RequestSpec, ctx map[string]any, opts RenderOptions) (RequestSpec, error) {
+	var errs []error
+
+	path, err := RenderTemplateWithOptions(req.Path, ctx, opts)
+	req.Path = path
+	errs = append(errs, err)
+
+	body, err := renderValue(req.Body, ctx, opts)
+	req.Body = body
+	errs = append(errs, err)
+
+	if req.Headers != nil {
+		headers := make(map[string]string, len(req.Headers))
+		for _, k := range sortedKeys(req.Headers) {
+			headers[k], err = RenderTemplateWithOptions(req.Headers[k], ctx, opts)
+			errs = append(errs, err)
+		}
+		req.Headers = headers
 	}
 
-	return req
+	return req, mergeRenderErrors(errs...)
 }
 
 func renderDBCheck(check DBCheck, ctx map[string]any) DBCheck {
-	check.Query = RenderTemplate(check.Query, ctx)
-	check.Result = RenderAny(check.Result, ctx)
+	rendered, _ := renderDBCheckWithOptions(check, ctx, DefaultRenderOptions())
+
+	return rendered
+}
+
+// renderDBCheckWithOptions renders the query and expected result and reports
+// every unresolved placeholder when opts is strict
+func renderDBCheckWithOptions(check DBCheck, ctx map[string]any, opts RenderOptions) (DBCheck, error) {
+	query, queryErr := RenderTemplateWithOptions(check.Query, ctx, opts)
+	check.Query = query
+
+	args, argsErr := renderArgs(check.Args, ctx, opts)
+	check.Args = args
+
+	result, resultErr := renderValue(check.Result, ctx, opts)
+	check.Result = result
+
+	return check, mergeRenderErrors(queryErr, argsErr, resultErr)
+}
+
+// renderArgs renders SQL bind parameters. A value that is a single placeholder
+// keeps the type it has in the context; maps and lists are passed as JSON
+func renderArgs(args []any, ctx map[string]any, opts RenderOptions) ([]any, error) {
+	if args == nil {
+		return nil, nil
+	}
+
+	rendered, err := renderValue(args, ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	out, _ := rendered.([]any)
+	for i, arg := range out {
+		switch arg.(type) {
+		case map[string]any, []any:
+			b, err := json.Marshal(arg)
+			if err != nil {
+				return nil, fmt.Errorf("arg %d: %w", i+1, err)
+			}
+			out[i] = string(b)
+		}
+	}
+
+	return out, nil
+}
+
+// renderResponse renders the expected response with the context so that
+// values captured in previous steps can be asserted
+func renderResponse(resp ResponseSpec, ctx map[string]any, opts RenderOptions) (ResponseSpec, error) {
+	var err error
+
+	if resp.JSON, err = renderJSONTemplate(resp.JSON, ctx, opts); err != nil {
+		return resp, fmt.Errorf("response.json: %w", err)
+	}
+
+	if resp.Text, err = RenderTemplateWithOptions(resp.Text, ctx, opts); err != nil {
+		return resp, fmt.Errorf("response.text: %w", err)
+	}
+
+	if resp.Schema, err = RenderTemplateWithOptions(resp.Schema, ctx, opts); err != nil {
+		return resp, fmt.Errorf("response.schema: %w", err)
+	}
+
+	if resp.Headers != nil {
+		headers := make(map[string]string, len(resp.Headers))
+		for _, k := range sortedKeys(resp.Headers) {
+			if headers[k], err = RenderTemplateWithOptions(resp.Headers[k], ctx, opts); err != nil {
+				return resp, fmt.Errorf("response.headers.%s: %w", k, err)
+			}
+		}
+		resp.Headers = headers
+	}
+
+	return resp, nil
+}
+
+// renderJSONTemplate renders placeholders inside a JSON document. A string
+// that is exactly one placeholder ("{{id}}") is replaced by the JSON encoding
+// of the context value, so numbers, booleans, objects and arrays keep their
+// type. Other placeholders are inserted as JSON-escaped text
+func renderJSONTemplate(input string, ctx map[string]any, opts RenderOptions) (string, error) {
+	result, quotedErr := replacePlaceholders(input, quotedPlaceholderRe, ctx, opts, func(val any) (string, error) {
+		buf, err := json.Marshal(val)
+
+		return string(buf), err
+	})
+
+	result, err := replacePlaceholders(result, placeholderRe, ctx, opts, func(val any) (string, error) {
+		buf, err := json.Marshal(fmt.Sprintf("%+v", val))
+
+		return strings.TrimSuffix(strings.TrimPrefix(string(buf), `"`), `"`), err
+	})
+
+	return result, mergeRenderErrors(quotedErr, err)
+}
+
+// sortedKeys returns the keys of m in order. Maps are walked in key order
+// wherever placeholders are rendered, so that seeded faker values are
+// drawn in the same order on every run
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
-	return check
+	return keys
 }
@@ -1,6 +1,11 @@
 package internal
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
 
 // RenderMode defines how to handle missing placeholders
 type RenderMode int
@@ -12,10 +17,42 @@ const (
 	RenderModeStrict
 )
 
+// ParseRenderMode parses a render mode name as used in YAML cases
+func ParseRenderMode(s string) (RenderMode, error) {
+	switch s {
+	case "permissive":
+		return RenderModePermissive, nil
+	case "strict":
+		return RenderModeStrict, nil
+	default:
+		return RenderModePermissive, fmt.Errorf("unknown render mode: %s (expected strict or permissive)", s)
+	}
+}
+
 // RenderOptions configures placeholder rendering behavior
 type RenderOptions struct {
 	Mode         RenderMode
-	DefaultValue string // used when Mode is Permissive and placeholder is missing
+	DefaultValue string         // used when Mode is Permissive and placeholder is missing
+	Faker        *FakerRegistry // generators for {{faker.*}} placeholders, defaults to the built-in set
+	Rand         *FakerSource   // random source of the generators, defaults to a randomly seeded one
+}
+
+// faker returns the registry used for faker placeholders
+func (o RenderOptions) faker() *FakerRegistry {
+	if o.Faker != nil {
+		return o.Faker
+	}
+
+	return defaultFakerRegistry
+}
+
+// rand returns the random source used for faker placeholders
+func (o RenderOptions) rand() *FakerSource {
+	if o.Rand != nil {
+		return o.Rand
+	}
+
+	return fakerSource
 }
 
 // DefaultRenderOptions returns the default rendering options
@@ -26,19 +63,111 @@ func DefaultRenderOptions() RenderOptions {
 	}
 }
 
+// UnresolvedPlaceholdersError is returned in strict mode and lists every
+// placeholder that was not found in the context
+type UnresolvedPlaceholdersError struct {
+	Keys []string
+}
+
+func (e *UnresolvedPlaceholdersError) Error() string {
+	return "unresolved placeholders: " + strings.Join(e.Keys, ", ")
+}
+
+// add records missing keys, skipping duplicates
+func (e *UnresolvedPlaceholdersError) add(keys ...string) {
+	for _, key := range keys {
+		found := false
+		for _, k := range e.Keys {
+			if k == key {
+				found = true
+
+				break
+			}
+		}
+		if !found {
+			e.Keys = append(e.Keys, key)
+		}
+	}
+}
+
+// mergeRenderErrors combines unresolved placeholder errors so that all
+// missing keys are reported at once; other errors take precedence
+func mergeRenderErrors(errs ...error) error {
+	var merged *UnresolvedPlaceholdersError
+
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		var unresolved *UnresolvedPlaceholdersError
+		if !errors.As(err, &unresolved) {
+			return err
+		}
+
+		if merged == nil {
+			merged = &UnresolvedPlaceholdersError{}
+		}
+		merged.add(unresolved.Keys...)
+	}
+
+	if merged == nil {
+		return nil
+	}
+
+	return merged
+}
+
 // RenderTemplateWithOptions renders a template with the given options
 func RenderTemplateWithOptions(input string, ctx map[string]any, opts RenderOptions) (string, error) {
-	var lastErr error
+	return replacePlaceholders(input, placeholderRe, ctx, opts, func(val any) (string, error) {
+		return fmt.Sprintf("%+v", val), nil
+	})
+}
+
+// replacePlaceholders substitutes every match of re, whose first group is the
+// placeholder key, with the formatted value. Unresolved placeholders are
+// handled according to opts
+func replacePlaceholders(
+	input string,
+	re *regexp.Regexp,
+	ctx map[string]any,
+	opts RenderOptions,
+	format func(any) (string, error),
+) (string, error) {
+	var unresolved *UnresolvedPlaceholdersError
+	var formatErr error
+
+	result := re.ReplaceAllStringFunc(input, func(match string) string {
+		key := re.FindStringSubmatch(match)[1]
+		val, err := evalPlaceholder(key, ctx, opts)
+		if err == nil {
+			formatted, err := format(val)
+			if err != nil {
+				formatErr = fmt.Errorf("cannot format placeholder %s: %w", key, err)
 
-	result := placeholderRe.ReplaceAllStringFunc(input, func(match string) string {
-		key := placeholderRe.FindStringSubmatch(match)[1]
-		if val, ok := ctx[key]; ok {
-			return fmt.Sprintf("%+v", val)
+				return match
+			}
+
+			return formatted
+		}
+
+		var missing *UnresolvedPlaceholdersError
+		if !errors.As(err, &missing) {
+			// Invalid expressions fail in strict mode and are kept otherwise
+			if opts.Mode == RenderModeStrict && formatErr == nil {
+				formatErr = fmt.Errorf("placeholder {{%s}}: %w", key, err)
+			}
+
+			return match
 		}
 
 		// Handle missing placeholder based on mode
 		if opts.Mode == RenderModeStrict {
-			lastErr = fmt.Errorf("placeholder not found in context: %s", key)
+			if unresolved == nil {
+				unresolved = &UnresolvedPlaceholdersError{}
+			}
+			unresolved.add(missing.Keys...)
 
 			return match
 		}
@@ -51,7 +180,14 @@ func RenderTemplateWithOptions(input string, ctx map[string]any, opts RenderOpti
 		return match
 	})
 
-	return result, lastErr
+	if formatErr != nil {
+		return result, formatErr
+	}
+	if unresolved != nil {
+		return result, unresolved
+	}
+
+	return result, nil
 }
 
 // RenderAnyWithOptions renders any value with the given options
@@ -61,23 +197,27 @@ func RenderAnyWithOptions(v any, ctx map[string]any, opts RenderOptions) (any, e
 		return RenderTemplateWithOptions(val, ctx, opts)
 	case map[string]any:
 		out := make(map[string]any, len(val))
+		var errs []error
 		for k, v2 := range val {
 			rendered, err := RenderAnyWithOptions(v2, ctx, opts)
-			if err != nil {
-				return nil, err
-			}
+			errs = append(errs, err)
 			out[k] = rendered
 		}
+		if err := mergeRenderErrors(errs...); err != nil {
+			return nil, err
+		}
 		return out, nil
 	case []any:
 		out := make([]any, len(val))
+		var errs []error
 		for i, v2 := range val {
 			rendered, err := RenderAnyWithOptions(v2, ctx, opts)
-			if err != nil {
-				return nil, err
-			}
+			errs = append(errs, err)
 			out[i] = rendered
 		}
+		if err := mergeRenderErrors(errs...); err != nil {
+			return nil, err
+		}
 		return out, nil
 	default:
 		return val, nil
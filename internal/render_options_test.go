@@ -2,6 +2,7 @@ package internal
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -275,3 +276,67 @@ func TestDefaultRenderOptions(t *testing.T) {
 		t.Errorf("DefaultRenderOptions().DefaultValue = %q, want empty string", opts.DefaultValue)
 	}
 }
+
+func TestRenderTemplateWithOptions_StrictModeListsAllPlaceholders(t *testing.T) {
+	_, err := RenderTemplateWithOptions("{{a}}/{{b}}/{{a}}/{{known}}", map[string]any{"known": 1}, RenderOptions{
+		Mode: RenderModeStrict,
+	})
+	if err == nil {
+		t.Fatal("Expected error for missing placeholders")
+	}
+
+	expected := "unresolved placeholders: a, b"
+	if err.Error() != expected {
+		t.Errorf("Expected error %q, got %q", expected, err.Error())
+	}
+}
+
+func TestRenderRequestWithOptions_StrictMode(t *testing.T) {
+	req := RequestSpec{
+		Method:  "POST",
+		Path:    "/users/{{userId}}",
+		Headers: map[string]string{"Authorization": "Bearer {{token}}"},
+		Body:    map[string]any{"name": "{{name}}", "tags": []any{"{{tag}}"}},
+	}
+
+	_, err := renderRequestWithOptions(req, map[string]any{"name": "John"}, RenderOptions{Mode: RenderModeStrict})
+	if err == nil {
+		t.Fatal("Expected error for missing placeholders")
+	}
+
+	for _, key := range []string{"userId", "token", "tag"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected error to mention %s, got %q", key, err.Error())
+		}
+	}
+	if strings.Contains(err.Error(), "name") {
+		t.Errorf("Resolved placeholder must not be reported, got %q", err.Error())
+	}
+	if req.Headers["Authorization"] != "Bearer {{token}}" {
+		t.Errorf("Original headers must not be modified, got %q", req.Headers["Authorization"])
+	}
+}
+
+func TestParseRenderMode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected RenderMode
+		wantErr  bool
+	}{
+		{input: "strict", expected: RenderModeStrict},
+		{input: "permissive", expected: RenderModePermissive},
+		{input: "lenient", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRenderMode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRenderMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseRenderMode() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
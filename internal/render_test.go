@@ -61,8 +61,6 @@ func TestRenderTemplate(t *testing.T) {
 }
 
 func TestRenderAny(t *testing.T) {
-	t.SkipNow()
-
 	ctx := map[string]any{
 		"name":    "John",
 		"age":     30,
@@ -94,7 +92,7 @@ func TestRenderAny(t *testing.T) {
 		{
 			name:     "array value",
 			input:    []any{"{{name}}", "{{age}}", "{{city}}"},
-			expected: []any{"John", "30", "New York"},
+			expected: []any{"John", 30, "New York"},
 		},
 		{
 			name: "nested structures",
@@ -112,7 +110,7 @@ func TestRenderAny(t *testing.T) {
 			expected: map[string]any{
 				"user": map[string]any{
 					"name": "John",
-					"age":  "30",
+					"age":  30,
 				},
 				"addresses": []any{
 					map[string]any{
@@ -143,9 +141,47 @@ func TestRenderAny(t *testing.T) {
 	}
 }
 
-func TestRenderRequest(t *testing.T) {
-	t.SkipNow()
+func TestRenderAny_TypePreserving(t *testing.T) {
+	ctx := map[string]any{
+		"id":      "00123",
+		"flag":    "true",
+		"user":    map[string]any{"name": "Alice", "tags": []any{"a", "b"}},
+		"tags":    []any{"a", "b"},
+		"count":   float64(3),
+		"nothing": nil,
+	}
+
+	got := RenderAny(map[string]any{
+		"id":       "{{id}}",
+		"flag":     "{{flag}}",
+		"user":     "{{user}}",
+		"tags":     "{{ tags }}",
+		"count":    "{{count}}",
+		"nothing":  "{{nothing}}",
+		"idNumber": "{{id | int}}",
+		"countStr": "{{count | string}}",
+		"text":     "id={{id}}",
+		"missing":  "{{missing}}",
+	}, ctx)
 
+	expected := map[string]any{
+		"id":       "00123",
+		"flag":     "true",
+		"user":     map[string]any{"name": "Alice", "tags": []any{"a", "b"}},
+		"tags":     []any{"a", "b"},
+		"count":    float64(3),
+		"nothing":  nil,
+		"idNumber": int64(123),
+		"countStr": "3",
+		"text":     "id=00123",
+		"missing":  "{{missing}}",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestRenderRequest(t *testing.T) {
 	ctx := map[string]any{
 		"userId":    123,
 		"userName":  "john_doe",
@@ -220,7 +256,7 @@ func TestRenderRequest(t *testing.T) {
 				},
 				Body: map[string]any{
 					"name":     "john_doe",
-					"id":       "123",
+					"id":       123,
 					"metadata": map[string]any{"token": "abc123xyz"},
 				},
 			},
@@ -325,6 +361,19 @@ func TestRenderDBCheck(t *testing.T) {
 				Result: "complex result",
 			},
 		},
+		{
+			name: "bind args keep context types",
+			input: DBCheck{
+				Query:  "SELECT * FROM users WHERE id = $1 AND name = $2 AND tags @> $3",
+				Args:   []any{"{{userId}}", "{{userName}}'s", []any{"{{status}}"}},
+				Result: "args result",
+			},
+			expected: DBCheck{
+				Query:  "SELECT * FROM users WHERE id = $1 AND name = $2 AND tags @> $3",
+				Args:   []any{123, "john_doe's", `["completed"]`},
+				Result: "args result",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -336,6 +385,10 @@ func TestRenderDBCheck(t *testing.T) {
 				t.Errorf("Query: expected %q, got %q", tt.expected.Query, got.Query)
 			}
 
+			if !reflect.DeepEqual(got.Args, tt.expected.Args) {
+				t.Errorf("Args: expected %#v, got %#v", tt.expected.Args, got.Args)
+			}
+
 			// Result should remain unchanged
 			if !reflect.DeepEqual(got.Result, tt.expected.Result) {
 				t.Errorf("Result: expected %v, got %v", tt.expected.Result, got.Result)
@@ -343,3 +396,60 @@ func TestRenderDBCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderResponse(t *testing.T) {
+	ctx := map[string]any{
+		"create_user.response.id": float64(42),
+		"name":                    `Alice "Al"`,
+		"tags":                    []any{"a", "b"},
+		"token":                   "abc",
+		"schemaDir":               "schemas",
+	}
+
+	resp := ResponseSpec{
+		Status:  200,
+		JSON:    `{"id": "{{create_user.response.id}}", "tags": "{{tags}}", "greeting": "Hi {{name}}", "name": "{{name}}"}`,
+		Text:    "token={{token}}",
+		Schema:  "{{schemaDir}}/user.json",
+		Headers: map[string]string{"X-Token": "{{token}}"},
+	}
+
+	got, err := renderResponse(resp, ctx, DefaultRenderOptions())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedJSON := `{"id": 42, "tags": ["a","b"], "greeting": "Hi Alice \"Al\"", "name": "Alice \"Al\""}`
+	if got.JSON != expectedJSON {
+		t.Errorf("JSON: expected %s, got %s", expectedJSON, got.JSON)
+	}
+	if got.Text != "token=abc" {
+		t.Errorf("Text: expected token=abc, got %s", got.Text)
+	}
+	if got.Schema != "schemas/user.json" {
+		t.Errorf("Schema: expected schemas/user.json, got %s", got.Schema)
+	}
+	if got.Headers["X-Token"] != "abc" {
+		t.Errorf("Headers: expected abc, got %s", got.Headers["X-Token"])
+	}
+	if resp.Headers["X-Token"] != "{{token}}" {
+		t.Errorf("Original headers must not be modified, got %s", resp.Headers["X-Token"])
+	}
+}
+
+func TestRenderResponse_Modes(t *testing.T) {
+	resp := ResponseSpec{JSON: `{"id": "{{missing}}"}`}
+
+	got, err := renderResponse(resp, map[string]any{}, DefaultRenderOptions())
+	if err != nil {
+		t.Fatalf("Permissive mode: unexpected error: %v", err)
+	}
+	if got.JSON != resp.JSON {
+		t.Errorf("Permissive mode: expected placeholder to be kept, got %s", got.JSON)
+	}
+
+	_, err = renderResponse(resp, map[string]any{}, RenderOptions{Mode: RenderModeStrict})
+	if err == nil {
+		t.Error("Strict mode: expected error for missing placeholder")
+	}
+}
@@ -1,11 +1,14 @@
 package internal
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -21,143 +24,348 @@ type TestCaseResult struct {
 // RunSingle runs a single test case with all features integrated
 func RunSingle(t *testing.T, handler http.Handler, tc TestCase, cfg *Config) TestCaseResult {
 	t.Helper()
-	const op = "RunSingle"
 
-	start := time.Now()
 	res := TestCaseResult{Name: tc.Name}
 
+	t.Run(tc.Name, func(t *testing.T) {
+		runCase(t, handler, tc, cfg, &res)
+	})
+
+	return res
+}
+
+// Acquirer hands out an isolated handler and config for a parallel test case
+// and returns a function releasing them
+type Acquirer func(t *testing.T) (http.Handler, *Config, func())
+
+// RunParallel runs a test case as a parallel subtest. The result is filled in
+// when the subtest completes, which happens after the parent test function returns
+func RunParallel(t *testing.T, tc TestCase, acquire Acquirer, res *TestCaseResult) {
+	t.Helper()
+
+	res.Name = tc.Name
+
+	t.Run(tc.Name, func(t *testing.T) {
+		t.Parallel()
+
+		handler, cfg, release := acquire(t)
+		defer release()
+
+		runCase(t, handler, tc, cfg, res)
+	})
+}
+
+// runCase executes the test case body inside its subtest
+func runCase(t *testing.T, handler http.Handler, tc TestCase, cfg *Config, res *TestCaseResult) {
+	t.Helper()
+	const op = "runCase"
+
+	start := time.Now()
+
 	defer func() {
 		res.Duration = time.Since(start)
 		if r := recover(); r != nil {
 			res.ErrMsg = fmt.Sprint(r)
 			panic(r)
 		}
+		if t.Failed() && res.ErrMsg == "" {
+			res.ErrMsg = "test case failed"
+		}
 	}()
 
-	t.Run(tc.Name, func(t *testing.T) {
-		// Initialize context with environment variables
-		ctxMap := initCtxMap()
+	// Every case draws fake values from its own source, so seeded values do
+	// not depend on the order cases run in
+	caseCfg := *cfg
+	caseCfg.RenderOptions.Rand = NewFakerSource(cfg.FakerSeed, tc.Index)
+	cfg = &caseCfg
 
-		// Add test case variables
-		if tc.Variables != nil {
-			for k, v := range tc.Variables {
-				ctxMap[k] = v
-			}
+	// Apply the case-level render mode override
+	if tc.RenderMode != "" {
+		mode, err := ParseRenderMode(tc.RenderMode)
+		if err != nil {
+			modeErr := NewError(ErrInvalidInput, op, "invalid render mode").
+				WithContext("file", tc.File).
+				WithContext("case", tc.Name).
+				WithContext("error", err.Error())
+			t.Fatalf("%+v", modeErr)
 		}
 
-		// Setup mocks
-		for name, def := range tc.Mocks {
-			inst := FindMockInstance(cfg.Mocks, name)
-			if inst == nil {
-				mockErr := NewError(ErrMock, op, "mock not found").
-					WithContext("mock", name)
-				t.Fatalf("%+v", mockErr)
-			}
+		cfg.RenderOptions.Mode = mode
+	}
 
-			for _, route := range def.Routes {
-				inst.router.AddRoute(route)
-			}
+	// Initialize context with environment variables
+	ctxMap := initCtxMap()
 
-			ctxMap[name+".baseURL"] = inst.url
-			ctxMap[name+".calls"] = inst.router.spy.Calls
+	// Add test case variables
+	if tc.Variables != nil {
+		for k, v := range tc.Variables {
+			ctxMap[k] = v
 		}
+	}
+
+	// Setup mocks: every case starts with its own routes and an empty call log
+	for name := range tc.Mocks {
+		if FindMockInstance(cfg.Mocks, name) == nil {
+			mockErr := NewError(ErrMock, op, "mock not found").
+				WithContext("mock", name)
+			t.Fatalf("%+v", mockErr)
+		}
+	}
 
-		// Setup hook executor
-		var db *sql.DB
-		var hookExecutor *HookExecutor
-		var testServer *httptest.Server
+	for _, inst := range cfg.Mocks {
+		if err := inst.router.Reset(tc.Mocks[inst.name].Routes); err != nil {
+			mockErr := NewError(ErrMock, op, "failed to set up mock routes").
+				WithContext("mock", inst.name).
+				WithContext("error", err.Error())
+			t.Fatalf("%+v", mockErr)
+		}
 
-		// Create test server for HTTP hooks if handler is provided
-		if handler != nil {
-			testServer = httptest.NewServer(handler)
-			defer testServer.Close()
+		if _, ok := tc.Mocks[inst.name]; ok {
+			ctxMap[inst.name+".baseURL"] = inst.url
+			ctxMap[inst.name+".calls"] = []any{}
 		}
+	}
+	setMockRenderContext(cfg.Mocks, ctxMap, cfg.RenderOptions)
+
+	// Apply the isolation mode; a transaction replaces the database and the
+	// handler for the whole case
+	db := cfg.Database
+	isolation := cfg.Isolation
+	if tc.Isolation != "" {
+		mode, err := ParseIsolationMode(tc.Isolation)
+		if err != nil {
+			modeErr := NewError(ErrInvalidInput, op, "invalid isolation mode").
+				WithContext("file", tc.File).
+				WithContext("case", tc.Name).
+				WithContext("error", err.Error())
+			t.Fatalf("%+v", modeErr)
+		}
+		isolation = mode
+	}
 
-		if cfg.ConnStr != "" {
-			var err error
-			db, err = sql.Open("postgres", cfg.ConnStr)
-			if err == nil {
-				defer func() { _ = db.Close() }()
+	switch isolation {
+	case IsolationTransaction:
+		txDB, txHandler, rollback := beginCaseTx(t, &tc, cfg)
+		defer rollback()
+		db, handler = txDB, txHandler
+	case IsolationSnapshot:
+		if cfg.Snapshot == "" {
+			snapErr := NewError(ErrInvalidInput, op, "snapshot isolation requires a PostgreSQL ConnStr").
+				WithContext("file", tc.File).
+				WithContext("case", tc.Name)
+			t.Fatalf("%+v", snapErr)
+		}
+		defer func() {
+			if err := RestorePostgresDatabase(context.Background(), cfg.ConnStr, cfg.Snapshot); err != nil {
+				t.Errorf("%+v", err)
 			}
+		}()
+	}
+
+	if handler == nil {
+		handlerErr := NewError(ErrInvalidInput, op, "no handler: set Handler or use transaction isolation").
+			WithContext("file", tc.File).
+			WithContext("case", tc.Name)
+		t.Fatalf("%+v", handlerErr)
+	}
+
+	// Setup hook executor
+	var hookExecutor *HookExecutor
+	var testServer *httptest.Server
+
+	// Remote targets are reached directly, in-process handlers get a
+	// test server for HTTP hooks
+	remote, isRemote := handler.(*RemoteHandler)
+	if handler != nil && !isRemote {
+		testServer = httptest.NewServer(handler)
+		defer testServer.Close()
+	}
+
+	if db == nil && cfg.ConnStr != "" {
+		sqlDB, err := OpenDB(cfg.DBType, cfg.ConnStr)
+		if err == nil {
+			defer func() { _ = sqlDB.Close() }()
+			db = NewSQLDatabase(sqlDB, cfg.DBType, cfg.ConnStr)
 		}
+	}
 
-		// Create hook executor with test server URL if available
-		baseURL := ""
-		if testServer != nil {
-			baseURL = testServer.URL
+	// Create hook executor with test server URL if available
+	baseURL := ""
+	var hookClient *http.Client
+	if testServer != nil {
+		baseURL = testServer.URL
+	} else if isRemote {
+		baseURL = remote.BaseURL()
+		hookClient = remote.Client()
+	}
+	hookExecutor = NewHookExecutor(db, baseURL).
+		WithHTTPClient(hookClient).
+		WithRenderOptions(cfg.RenderOptions)
+
+	// Execute setup hooks
+	if len(tc.Setup) > 0 && hookExecutor != nil {
+		if err := ExecuteSetup(hookExecutor, tc.Setup, ctxMap); err != nil {
+			hookErr := NewError(ErrInternal, op, "setup failed").
+				WithContext("file", tc.File).
+				WithContext("case", tc.Name).
+				WithContext("error", err.Error())
+			t.Fatalf("%+v", hookErr)
 		}
-		hookExecutor = NewHookExecutor(db, baseURL)
+	}
+
+	// Setup deferred teardown
+	if len(tc.Teardown) > 0 && hookExecutor != nil {
+		defer func() {
+			if err := ExecuteTeardown(hookExecutor, tc.Teardown, ctxMap); err != nil {
+				t.Logf("Warning: teardown failed: %v", err)
+			}
+		}()
+	}
+
+	// Load fixtures
+	LoadCaseFixtures(t, db, cfg.FixturesDir, tc.Fixtures, ctxMap, cfg.RenderOptions)
+
+	// Execute steps, each one as its own subtest
+	var failedSteps []string
+	for _, step := range tc.Steps {
+		step.Name = strings.ReplaceAll(step.Name, " ", "_")
 
-		// Execute setup hooks
-		if len(tc.Setup) > 0 && hookExecutor != nil {
-			if err := ExecuteSetup(hookExecutor, tc.Setup, ctxMap); err != nil {
-				t.Fatalf("Setup failed: %v", err)
+		if !runStep(t, handler, &tc, step, cfg, ctxMap, db) {
+			failedSteps = append(failedSteps, step.Name)
+			if !tc.ContinueOnFailure {
+				break
 			}
 		}
+	}
 
-		// Setup deferred teardown
-		if len(tc.Teardown) > 0 && hookExecutor != nil {
-			defer func() {
-				if err := ExecuteTeardown(hookExecutor, tc.Teardown, ctxMap); err != nil {
-					t.Logf("Warning: teardown failed: %v", err)
-				}
-			}()
+	if len(failedSteps) > 0 {
+		res.ErrMsg = "failed steps: " + strings.Join(failedSteps, ", ")
+		if !tc.ContinueOnFailure {
+			t.FailNow()
 		}
+	}
 
-		// Load fixtures
-		LoadFixturesFromList(t, cfg.DBType, cfg.ConnStr, cfg.FixturesDir, tc.Fixtures)
+	// Assert mock calls
+	AssertMockCalls(t, tc.MockCalls, cfg.Mocks)
+}
 
-		// Execute steps
-		for _, step := range tc.Steps {
-			step.Name = strings.ReplaceAll(step.Name, " ", "_")
+// beginCaseTx starts the transaction of a case and builds the handler bound
+// to it. The returned function rolls the transaction back
+func beginCaseTx(t *testing.T, tc *TestCase, cfg *Config) (Database, http.Handler, func()) {
+	t.Helper()
+	const op = "beginCaseTx"
 
-			// Check if a step should execute (conditional)
-			if step.When != "" {
-				shouldExecute, err := ShouldExecuteStep(step, ctxMap)
-				if err != nil {
-					t.Fatalf("Failed to evaluate condition for step %s: %v", step.Name, err)
-				}
-				if !shouldExecute {
-					t.Logf("Skipping step %s (condition not met)", step.Name)
-					continue
-				}
-			}
+	if cfg.SQLDB == nil || cfg.TxHandler == nil {
+		txErr := NewError(ErrInvalidInput, op, "transaction isolation requires a database/sql connection and TxHandler").
+			WithContext("file", tc.File).
+			WithContext("case", tc.Name)
+		t.Fatalf("%+v", txErr)
+	}
 
-			// Handle loops
-			if step.Loop != nil {
-				contexts, err := ExpandLoop(step.Loop, ctxMap)
-				if err != nil {
-					t.Fatalf("Failed to expand loop for step %s: %v", step.Name, err)
-				}
+	tx, err := cfg.SQLDB.BeginTx(context.Background(), nil)
+	if err != nil {
+		txErr := NewError(ErrDatabase, op, "failed to begin transaction").
+			WithContext("case", tc.Name).
+			WithContext("error", err.Error())
+		t.Fatalf("%+v", txErr)
+	}
 
-				for i, loopCtx := range contexts {
-					loopStepName := fmt.Sprintf("%s[%d]", step.Name, i)
-					loopStep := step
-					loopStep.Name = loopStepName
-					performStep(t, handler, loopStep, cfg, loopCtx, db)
-				}
-			} else {
-				performStep(t, handler, step, cfg, ctxMap, db)
+	rollback := func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			t.Errorf("%+v", NewError(ErrDatabase, op, "failed to roll back transaction").
+				WithContext("case", tc.Name).
+				WithContext("error", err.Error()))
+		}
+	}
+
+	handler, err := cfg.TxHandler(tx)
+	if err != nil {
+		rollback()
+		txErr := NewError(ErrInternal, op, "failed to build transaction handler").
+			WithContext("case", tc.Name).
+			WithContext("error", err.Error())
+		t.Fatalf("%+v", txErr)
+	}
+
+	return newTxDatabase(tx, cfg.DBType), handler, rollback
+}
+
+// runStep runs a step as a subtest, expanding loops into one subtest per
+// iteration. It reports whether the step passed
+func runStep(
+	t *testing.T,
+	handler http.Handler,
+	tc *TestCase,
+	step Step,
+	cfg *Config,
+	ctxMap map[string]any,
+	db Database,
+) bool {
+	t.Helper()
+	const op = "runStep"
+
+	return t.Run(step.Name, func(t *testing.T) {
+		// Check if a step should execute (conditional)
+		if step.When != "" {
+			shouldExecute, err := EvaluateConditionWithOptions(step.When, ctxMap, cfg.RenderOptions)
+			if err != nil {
+				t.Fatalf("%+v", stepError(op, tc, step.Name, "failed to evaluate condition", err))
 			}
+			if !shouldExecute {
+				t.Skipf("Skipping step %s (condition not met)", step.Name)
+			}
+		}
+
+		if step.Loop == nil {
+			performStep(t, handler, tc, step, cfg, ctxMap, db)
+
+			return
 		}
 
-		// Assert mock calls
-		AssertMockCalls(t, tc.MockCalls, cfg.Mocks)
+		contexts, err := ExpandLoopWithOptions(step.Loop, ctxMap, cfg.RenderOptions)
+		if err != nil {
+			t.Fatalf("%+v", stepError(op, tc, step.Name, "failed to expand loop", err))
+		}
+
+		for i, loopCtx := range contexts {
+			loopStep := step
+			loopStep.Name = fmt.Sprintf("%s[%d]", step.Name, i)
+
+			passed := t.Run(strconv.Itoa(i), func(t *testing.T) {
+				performStep(t, handler, tc, loopStep, cfg, loopCtx, db)
+			})
+			if !passed && !tc.ContinueOnFailure {
+				return
+			}
+		}
 	})
+}
 
-	return res
+// stepError wraps a step failure with the file, case and step it occurred in
+func stepError(op string, tc *TestCase, step, message string, err error) *Error {
+	return NewError(ErrInvalidInput, op, message).
+		WithContext("file", tc.File).
+		WithContext("case", tc.Name).
+		WithContext("step", step).
+		WithContext("error", err.Error())
 }
 
 // performStep executes a single step with all features
-func performStep(t *testing.T, handler http.Handler, step Step, cfg *Config, ctxMap map[string]any, db *sql.DB) {
+func performStep(
+	t *testing.T,
+	handler http.Handler,
+	tc *TestCase,
+	step Step,
+	cfg *Config,
+	ctxMap map[string]any,
+	db Database,
+) {
 	t.Helper()
 	const op = "performStep"
 
-	// Expand faker placeholders in context (modify in place to preserve extracted fields)
-	expanded := expandFakerInContext(ctxMap)
-	for k, v := range expanded {
-		ctxMap[k] = v
-	}
+	// Expand faker placeholders in context values (modified in place to preserve extracted fields)
+	expandFakerInContext(ctxMap, cfg.RenderOptions)
+	refreshMockCalls(ctxMap, cfg.Mocks)
 
 	// BeforeReq hook
 	if cfg.BeforeReq != nil {
@@ -169,6 +377,52 @@ func performStep(t *testing.T, handler http.Handler, step Step, cfg *Config, ctx
 		}
 	}
 
+	// Render request with context
+	renderedReq, err := renderRequestWithOptions(step.Request, ctxMap, cfg.RenderOptions)
+	if err != nil {
+		t.Fatalf("%+v", stepError(op, tc, step.Name, "failed to render request", err))
+	}
+	step.Request = renderedReq
+
+	// Step mock routes are active until the step ends
+	for name, def := range step.Mocks {
+		inst := FindMockInstance(cfg.Mocks, name)
+		if inst == nil {
+			mockErr := NewError(ErrMock, op, "mock not found").
+				WithContext("step", step.Name).
+				WithContext("mock", name)
+			t.Fatalf("%+v", mockErr)
+		}
+
+		restore, err := inst.router.Overlay(def.Routes)
+		if err != nil {
+			t.Fatalf("%+v", stepError(op, tc, step.Name, "failed to set up step mock routes", err))
+		}
+		defer restore()
+	}
+	setMockRenderContext(cfg.Mocks, ctxMap, cfg.RenderOptions)
+
+	var mockCallsBefore map[string]int
+	if len(step.MockCalls) > 0 {
+		mockCallsBefore = MockCallCounts(cfg.Mocks)
+	}
+
+	// Snapshot the tables of a dbDiff before the request
+	var diffBefore map[string][]map[string]any
+	if len(step.DBDiff) > 0 {
+		if db == nil {
+			dbErr := NewError(ErrDatabase, op, "dbDiff requires a database").
+				WithContext("file", tc.File).
+				WithContext("case", tc.Name).
+				WithContext("step", step.Name)
+			t.Fatalf("%+v", dbErr)
+		}
+		diffBefore, err = SnapshotTables(t.Context(), db, cfg.DBType, step.DBDiff)
+		if err != nil {
+			t.Fatalf("%+v", stepError(op, tc, step.Name, "failed to read dbDiff tables", err))
+		}
+	}
+
 	var rec *httptest.ResponseRecorder
 	var requestDuration time.Duration
 
@@ -182,7 +436,7 @@ func performStep(t *testing.T, handler http.Handler, step Step, cfg *Config, ctx
 		expectedStatus := step.Response.Status
 		result := ExecuteWithRetry(parsedRetry, func() (int, error) {
 			startTime := time.Now()
-			rec = ExecuteRequest(t, step, handler, ctxMap)
+			rec = executeRenderedRequest(t, step, handler)
 			requestDuration = time.Since(startTime)
 
 			// If status matches expected, it's not an error - proceed with validation
@@ -231,7 +485,7 @@ func performStep(t *testing.T, handler http.Handler, step Step, cfg *Config, ctx
 	} else {
 		// Single request execution
 		startTime := time.Now()
-		rec = ExecuteRequest(t, step, handler, ctxMap)
+		rec = executeRenderedRequest(t, step, handler)
 		requestDuration = time.Since(startTime)
 	}
 
@@ -244,6 +498,7 @@ func performStep(t *testing.T, handler http.Handler, step Step, cfg *Config, ctx
 			t.Fatalf("%+v", hookErr)
 		}
 	}
+	refreshMockCalls(ctxMap, cfg.Mocks)
 
 	// Performance validation
 	if step.Performance != nil {
@@ -293,21 +548,27 @@ func performStep(t *testing.T, handler http.Handler, step Step, cfg *Config, ctx
 		}
 	}
 
+	// Render expected response with context
+	expected, err := renderResponse(step.Response, ctxMap, cfg.RenderOptions)
+	if err != nil {
+		t.Fatalf("%+v", stepError(op, tc, step.Name, "failed to render expected response", err))
+	}
+
 	// Assert response
-	AssertResponse(t, rec, step.Response)
+	AssertResponse(t, rec, expected)
 
 	// JSON Schema validation
-	if step.Response.JSONSchema != nil || step.Response.Schema != "" {
+	if expected.JSONSchema != nil || expected.Schema != "" {
 		var schema JSONSchema
 		var err error
 
-		if step.Response.Schema != "" {
-			schema, err = LoadJSONSchemaFromFile(step.Response.Schema)
+		if expected.Schema != "" {
+			schema, err = LoadJSONSchemaFromFile(expected.Schema)
 			if err != nil {
 				t.Fatalf("Failed to load schema: %v", err)
 			}
 		} else {
-			schema = *step.Response.JSONSchema
+			schema = *expected.JSONSchema
 		}
 
 		var jsonData any
@@ -332,8 +593,15 @@ func performStep(t *testing.T, handler http.Handler, step Step, cfg *Config, ctx
 		} else {
 			for _, assertion := range step.Response.Assertions {
 				// Render assertion value with context
+				value, err := renderValue(assertion.Value, ctxMap, cfg.RenderOptions)
+				if err != nil {
+					t.Errorf("%+v", stepError(op, tc, step.Name, "failed to render assertion value", err))
+
+					continue
+				}
+
 				renderedAssertion := assertion
-				renderedAssertion.Value = RenderAny(assertion.Value, ctxMap)
+				renderedAssertion.Value = value
 				if err := AssertResponseV2(renderedAssertion, responseData); err != nil {
 					t.Errorf("Assertion failed: %v", err)
 				}
@@ -341,41 +609,35 @@ func performStep(t *testing.T, handler http.Handler, step Step, cfg *Config, ctx
 		}
 	}
 
+	// Extract named values once the response matched, so an unexpected
+	// response is reported as such rather than as a missing value
+	if len(step.Extract) > 0 && !t.Failed() {
+		if err := ExtractValues(step.Extract, rec, ctxMap); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+
 	// Execute DB checks
 	if len(step.DBChecks) > 0 && db != nil {
 		for _, check := range step.DBChecks {
-			check = renderDBCheck(check, ctxMap)
-			ExecuteDBCheck(t, db, check)
+			check, err := renderDBCheckWithOptions(check, ctxMap, cfg.RenderOptions)
+			if err != nil {
+				t.Fatalf("%+v", stepError(op, tc, step.Name, "failed to render dbCheck", err))
+			}
+			rows, ok := ExecuteDBCheck(t, db, check)
+			if ok && len(check.Extract) > 0 {
+				if err := ExtractRows(check.Extract, rows, ctxMap); err != nil {
+					t.Fatalf("%+v", stepError(op, tc, step.Name, "failed to extract dbCheck rows", err))
+				}
+			}
 		}
 	}
-}
-
-// expandFakerInContext expands faker placeholders in context values
-func expandFakerInContext(ctx map[string]any) map[string]any {
-	registry := NewFakerRegistry()
-	result := make(map[string]any)
 
-	for k, v := range ctx {
-		switch val := v.(type) {
-		case string:
-			result[k] = expandFakerInString(val, registry)
-		default:
-			result[k] = v
-		}
+	if diffBefore != nil {
+		AssertDBDiff(t, db, cfg.DBType, step.DBDiff, diffBefore, ctxMap, cfg.RenderOptions)
 	}
 
-	return result
-}
-
-// expandFakerInString replaces {{faker.xxx}} with generated values
-func expandFakerInString(input string, registry *FakerRegistry) string {
-	// Simple implementation - in production would use regex
-	for name := range registry.functions {
-		placeholder := fmt.Sprintf("{{faker.%s}}", name)
-		if strings.Contains(input, placeholder) {
-			generated, _ := registry.Generate(name)
-			input = strings.ReplaceAll(input, placeholder, generated)
-		}
+	if mockCallsBefore != nil {
+		AssertStepMockCalls(t, step.MockCalls, cfg.Mocks, mockCallsBefore)
 	}
-	return input
 }
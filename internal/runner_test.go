@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRunSingle_StepSubtests(t *testing.T) {
+	var paths []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 42})
+	})
+
+	tc := TestCase{
+		Name: "subtests",
+		Steps: []Step{
+			{
+				Name:     "create item",
+				Request:  RequestSpec{Method: "POST", Path: "/items"},
+				Response: ResponseSpec{Status: http.StatusOK},
+			},
+			{
+				Name:     "skipped",
+				When:     "{{create_item.response.id}} == 0",
+				Request:  RequestSpec{Method: "GET", Path: "/never"},
+				Response: ResponseSpec{Status: http.StatusOK},
+			},
+			{
+				Name:     "fetch",
+				Loop:     &LoopConfig{Items: []any{"a", "b"}, Var: "item"},
+				Request:  RequestSpec{Method: "GET", Path: "/items/{{create_item.response.id}}/{{item}}"},
+				Response: ResponseSpec{Status: http.StatusOK},
+			},
+		},
+	}
+
+	res := RunSingle(t, handler, tc, &Config{})
+	if res.ErrMsg != "" {
+		t.Fatalf("Expected no error, got %q", res.ErrMsg)
+	}
+
+	expected := []string{"/items", "/items/42/a", "/items/42/b"}
+	if len(paths) != len(expected) {
+		t.Fatalf("Expected %d requests, got %v", len(expected), paths)
+	}
+	for i, p := range expected {
+		if paths[i] != p {
+			t.Errorf("Request %d: expected path %s, got %s", i, p, paths[i])
+		}
+	}
+}
+
+// continueOnFailureEnv selects the case run by the child process of
+// TestRunSingle_ContinueOnFailure. A failing case fails its parent test, so
+// it is run in a separate test binary process
+const continueOnFailureEnv = "TESTY_CONTINUE_ON_FAILURE_CHILD"
+
+func TestRunSingle_ContinueOnFailure(t *testing.T) {
+	if mode := os.Getenv(continueOnFailureEnv); mode != "" {
+		runContinueOnFailureChild(t, mode == "continue")
+
+		return
+	}
+
+	tests := []struct {
+		name          string
+		mode          string
+		expectedPaths string
+		expectedErr   string
+	}{
+		{"stop by default", "stop", "/ok1 /fail1", "failed steps: fail1"},
+		{"continue on failure", "continue", "/ok1 /fail1 /ok2 /fail2 /ok3", "failed steps: fail1, fail2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestRunSingle_ContinueOnFailure$")
+			cmd.Env = append(os.Environ(), continueOnFailureEnv+"="+tt.mode)
+			out, _ := cmd.CombinedOutput()
+			output := string(out)
+
+			if !strings.Contains(output, "paths="+tt.expectedPaths+"\n") {
+				t.Errorf("Expected requests %q, got output:\n%s", tt.expectedPaths, output)
+			}
+			if !strings.Contains(output, "error="+tt.expectedErr+"\n") {
+				t.Errorf("Expected case error %q, got output:\n%s", tt.expectedErr, output)
+			}
+			if !strings.Contains(output, "case failed=true\n") {
+				t.Errorf("Expected the case to fail, got output:\n%s", output)
+			}
+		})
+	}
+}
+
+// runContinueOnFailureChild runs a case whose fail steps get an unexpected
+// status and prints what happened for the parent process
+func runContinueOnFailureChild(t *testing.T, continueOnFailure bool) {
+	var paths []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if strings.HasPrefix(r.URL.Path, "/fail") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tc := TestCase{Name: "steps", ContinueOnFailure: continueOnFailure}
+	for _, name := range []string{"ok1", "fail1", "ok2", "fail2", "ok3"} {
+		tc.Steps = append(tc.Steps, Step{
+			Name:     name,
+			Request:  RequestSpec{Method: "GET", Path: "/" + name},
+			Response: ResponseSpec{Status: http.StatusOK},
+		})
+	}
+
+	res := RunSingle(t, handler, tc, &Config{})
+
+	fmt.Printf("paths=%s\n", strings.Join(paths, " "))
+	fmt.Printf("error=%s\n", res.ErrMsg)
+	fmt.Printf("case failed=%v\n", t.Failed())
+}
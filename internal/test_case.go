@@ -1,14 +1,72 @@
 package internal
 
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
 type TestCase struct {
 	Name      string                   `yaml:"name"`
 	Variables map[string]any           `yaml:"variables,omitempty"`
-	Fixtures  []string                 `yaml:"fixtures,omitempty"`
+	Fixtures  []Fixture                `yaml:"fixtures,omitempty"`
 	Mocks     map[string]MockServerDef `yaml:"mockServers,omitempty"`
 	MockCalls []MockCallCheck          `yaml:"mockCalls,omitempty"`
 	Setup     []Hook                   `yaml:"setup,omitempty"`
 	Teardown  []Hook                   `yaml:"teardown,omitempty"`
 	Steps     []Step                   `yaml:"steps"`
+
+	// ContinueOnFailure keeps running the remaining steps after a step fails
+	ContinueOnFailure bool `yaml:"continueOnFailure,omitempty"`
+	// Serial excludes the case from parallel execution
+	Serial bool `yaml:"serial,omitempty"`
+	// RenderMode overrides the configured render mode: strict | permissive
+	RenderMode string `yaml:"renderMode,omitempty"`
+	// Isolation overrides the configured isolation mode: none | transaction | snapshot
+	Isolation string `yaml:"isolation,omitempty"`
+
+	// File is the path of the YAML file the case was loaded from
+	File string `yaml:"-"`
+	// Index is the position of the case in the suite
+	Index int `yaml:"-"`
+}
+
+// Fixture is an entry of a case's fixtures: the name of a file in
+// FixturesDir, or table rows declared inline
+type Fixture struct {
+	File  string           `yaml:"-"`
+	Table string           `yaml:"table"`
+	Rows  []map[string]any `yaml:"rows"`
+}
+
+// UnmarshalYAML accepts a file name or a {table, rows} mapping
+func (f *Fixture) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		f.File = node.Value
+
+		return nil
+	}
+
+	type inline Fixture
+	var v inline
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	if v.Table == "" {
+		return fmt.Errorf("line %d: inline fixture requires a table", node.Line)
+	}
+	*f = Fixture(v)
+
+	return nil
+}
+
+// String returns the file name or the table of an inline fixture
+func (f Fixture) String() string {
+	if f.File != "" {
+		return f.File
+	}
+
+	return "inline " + f.Table
 }
 
 type Step struct {
@@ -20,12 +78,51 @@ type Step struct {
 	Response    ResponseSpec     `yaml:"response"`
 	Performance *PerformanceSpec `yaml:"performance,omitempty"`
 	DBChecks    []DBCheck        `yaml:"dbChecks,omitempty"`
+	// DBDiff asserts the rows inserted, updated and deleted by the request
+	DBDiff []DBDiffTable `yaml:"dbDiff,omitempty"`
+
+	// Mocks adds mock routes for this step only, on top of the case routes
+	Mocks map[string]MockServerDef `yaml:"mockServers,omitempty"`
+	// MockCalls checks the calls made to mocks during this step only
+	MockCalls []MockCallCheck `yaml:"mockCalls,omitempty"`
+
+	// Extract stores values captured from the response under short variable names
+	Extract map[string]ExtractSpec `yaml:"extract,omitempty"`
+}
+
+// ExtractSpec describes where a value is captured from
+type ExtractSpec struct {
+	From  string `yaml:"from"`            // body | header | status
+	Path  string `yaml:"path,omitempty"`  // JSON path inside the body
+	Name  string `yaml:"name,omitempty"`  // header name
+	Regex string `yaml:"regex,omitempty"` // first capture group (or whole match) of the body or header
 }
 
 type LoopConfig struct {
-	Items []any  `yaml:"items"`
-	Var   string `yaml:"var"`
-	Range *Range `yaml:"range,omitempty"`
+	Items LoopItems `yaml:"items"`
+	Var   string    `yaml:"var"`
+	Range *Range    `yaml:"range,omitempty"`
+}
+
+// LoopItems is the list of loop items. In YAML it is either a sequence or a
+// single placeholder evaluating to a list: items: "{{ ids }}"
+type LoopItems []any
+
+// UnmarshalYAML accepts a sequence or a single scalar
+func (l *LoopItems) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = LoopItems{node.Value}
+
+		return nil
+	}
+
+	var items []any
+	if err := node.Decode(&items); err != nil {
+		return err
+	}
+	*l = items
+
+	return nil
 }
 
 type Range struct {
@@ -62,7 +159,31 @@ type ResponseAssertion struct {
 
 type DBCheck struct {
 	Query  string `yaml:"query"`
+	Args   []any  `yaml:"args,omitempty"` // bind parameters for $1/? in the query
 	Result any    `yaml:"result"`
+
+	// Match selects how rows are compared: exact (default), unordered or subset
+	Match string `yaml:"match,omitempty"`
+	// RowCount asserts the number of returned rows
+	RowCount *int `yaml:"rowCount,omitempty"`
+
+	// Eventually re-runs the query until the result matches
+	Eventually *EventuallySpec `yaml:"eventually,omitempty"`
+
+	// Extract stores returned rows in the context under variable names
+	Extract map[string]DBExtractSpec `yaml:"extract,omitempty"`
+}
+
+// DBExtractSpec describes which rows and columns of a query are captured
+type DBExtractSpec struct {
+	Rows    string   `yaml:"rows,omitempty"`    // first (default) | all
+	Columns []string `yaml:"columns,omitempty"` // captured columns, all by default
+}
+
+// EventuallySpec configures polling for a dbCheck
+type EventuallySpec struct {
+	Timeout  string `yaml:"timeout"`            // Maximum time to wait (e.g., "5s")
+	Interval string `yaml:"interval,omitempty"` // Delay between queries, default 100ms
 }
 
 type MockCallExpect struct {
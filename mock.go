@@ -6,6 +6,9 @@ import (
 	"github.com/rom8726/testy/v2/internal"
 )
 
+// MockCall is a request received by a mock server
+type MockCall = internal.MockCall
+
 type MockInstance struct {
 	name   string
 	server *httptest.Server
@@ -50,6 +53,19 @@ func (m *MockManager) URL(name string) string {
 	return ""
 }
 
+// Calls returns a copy of the requests received by the named mock server,
+// or nil if there is no such mock. It is safe to call while requests are
+// being served
+func (m *MockManager) Calls(name string) []MockCall {
+	for _, inst := range m.instances {
+		if inst.name == name {
+			return inst.router.Spy().Calls()
+		}
+	}
+
+	return nil
+}
+
 func (m *MockManager) internalInstances() []*internal.MockInstance {
 	res := make([]*internal.MockInstance, 0, len(m.instances))
 	for _, inst := range m.instances {
@@ -58,3 +74,12 @@ func (m *MockManager) internalInstances() []*internal.MockInstance {
 
 	return res
 }
+
+func (m *MockManager) names() []string {
+	res := make([]string, 0, len(m.instances))
+	for _, inst := range m.instances {
+		res = append(res, inst.name)
+	}
+
+	return res
+}
@@ -0,0 +1,156 @@
+package testy
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/rom8726/testy/v2/internal"
+)
+
+// Worker describes the isolated environment of a parallel worker. When
+// Config.Handler is nil, serial cases run on a handler built for worker 0:
+// the original database and mock servers
+type Worker struct {
+	ID int
+	// ConnStr points to the worker's own database cloned from Config.ConnStr
+	ConnStr string
+	// MockManager holds the worker's own mock servers
+	MockManager *MockManager
+}
+
+type worker struct {
+	Worker
+	dbName  string
+	handler http.Handler
+	cfg     *internal.Config
+}
+
+type workerPool struct {
+	connStr string
+	workers []*worker
+	free    chan *worker
+}
+
+// startWorkers prepares cfg.Parallel isolated workers
+func startWorkers(t *testing.T, cfg *Config, handler http.Handler, base internal.Config) (*workerPool, error) {
+	t.Helper()
+
+	pool := &workerPool{
+		connStr: cfg.ConnStr,
+		free:    make(chan *worker, cfg.Parallel),
+	}
+
+	srcDBName := ""
+	if cfg.ConnStr != "" {
+		name, err := internal.PostgresDBName(cfg.ConnStr)
+		if err != nil {
+			return nil, err
+		}
+		srcDBName = name
+	}
+
+	for id := 1; id <= cfg.Parallel; id++ {
+		w := &worker{Worker: Worker{ID: id}, handler: handler}
+		pool.workers = append(pool.workers, w)
+
+		// Transactions and snapshots belong to the original database
+		workerCfg := base
+		workerCfg.SQLDB = nil
+		workerCfg.Snapshot = ""
+
+		if cfg.ConnStr != "" {
+			w.dbName = fmt.Sprintf("%s_testy_w%d", srcDBName, id)
+			connStr, err := internal.ClonePostgresDatabase(t.Context(), cfg.ConnStr, w.dbName)
+			if err != nil {
+				return pool, err
+			}
+			w.ConnStr = connStr
+			workerCfg.ConnStr = connStr
+		}
+
+		if cfg.MockManager != nil {
+			mocks, err := StartMockManager(cfg.MockManager.names()...)
+			if err != nil {
+				return pool, err
+			}
+			w.MockManager = mocks
+			workerCfg.Mocks = mocks.internalInstances()
+		}
+
+		if cfg.WorkerHandler != nil {
+			h, err := cfg.WorkerHandler(w.Worker)
+			if err != nil {
+				return pool, fmt.Errorf("worker %d: failed to build handler: %w", id, err)
+			}
+			w.handler = h
+		}
+
+		w.cfg = &workerCfg
+		pool.free <- w
+	}
+
+	return pool, nil
+}
+
+// serialHandler builds the handler of serial cases with WorkerHandler. It is
+// called after the worker databases are cloned, so the template database has
+// no open connections while cloning
+func serialHandler(cfg *Config, cases []internal.TestCase) (http.Handler, error) {
+	hasSerial := false
+	for _, tc := range cases {
+		hasSerial = hasSerial || tc.Serial
+	}
+	if !hasSerial {
+		return nil, nil
+	}
+
+	h, err := cfg.WorkerHandler(Worker{ConnStr: cfg.ConnStr, MockManager: cfg.MockManager})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build handler for serial cases: %w", err)
+	}
+
+	return h, nil
+}
+
+// checkParallelIsolation rejects cases that would run on a parallel worker
+// with transaction or snapshot isolation. Serial cases run on the original
+// database and may use it
+func checkParallelIsolation(cases []internal.TestCase) error {
+	for _, tc := range cases {
+		if tc.Serial || tc.Isolation == "" || tc.Isolation == string(IsolationNone) {
+			continue
+		}
+
+		return fmt.Errorf("case %q: isolation %s cannot be used on parallel workers, mark the case serial: true",
+			tc.Name, tc.Isolation)
+	}
+
+	return nil
+}
+
+// acquire waits for a free worker
+func (p *workerPool) acquire(t *testing.T) (http.Handler, *internal.Config, func()) {
+	t.Helper()
+
+	w := <-p.free
+	t.Logf("running on parallel worker %d", w.ID)
+
+	return w.handler, w.cfg, func() { p.free <- w }
+}
+
+// stop shuts down worker mocks and drops worker databases
+func (p *workerPool) stop(t *testing.T) {
+	t.Helper()
+
+	for _, w := range p.workers {
+		if w.MockManager != nil {
+			w.MockManager.StopAll()
+		}
+		if w.ConnStr != "" {
+			if err := internal.DropPostgresDatabase(t.Context(), p.connStr, w.dbName); err != nil {
+				t.Logf("%+v", err)
+			}
+		}
+	}
+}
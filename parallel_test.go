@@ -0,0 +1,179 @@
+package testy
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rom8726/testy/v2/internal"
+)
+
+func TestRun_Parallel(t *testing.T) {
+	casesDir := writeCases(t, `
+- name: first
+  steps:
+    - name: ping
+      request: {method: GET, path: /slow}
+      response: {status: 200}
+- name: second
+  steps:
+    - name: ping
+      request: {method: GET, path: /slow}
+      response: {status: 200}
+- name: serial
+  serial: true
+  steps:
+    - name: ping
+      request: {method: GET, path: /slow}
+      response: {status: 200}
+`)
+
+	var inFlight, maxInFlight, total atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		total.Add(1)
+
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	Run(t, &Config{
+		Handler:  handler,
+		CasesDir: casesDir,
+		Parallel: 2,
+	})
+
+	if total.Load() != 3 {
+		t.Errorf("Expected 3 requests, got %d", total.Load())
+	}
+	// go test -parallel may cap concurrency below the worker count
+	if maxInFlight.Load() > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, got %d", maxInFlight.Load())
+	}
+}
+
+func TestRun_ParallelSerialHandler(t *testing.T) {
+	casesDir := writeCases(t, `
+- name: parallel
+  steps:
+    - name: ping
+      request: {method: GET, path: /ping}
+      response: {status: 200}
+- name: serial
+  serial: true
+  steps:
+    - name: ping
+      request: {method: GET, path: /ping}
+      response: {status: 200}
+`)
+
+	var mu sync.Mutex
+	built := map[int]int{}
+	Run(t, &Config{
+		CasesDir: casesDir,
+		Parallel: 2,
+		WorkerHandler: func(w Worker) (http.Handler, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			built[w.ID]++
+
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}), nil
+		},
+	})
+
+	if built[0] != 1 || built[1] != 1 || built[2] != 1 {
+		t.Errorf("Expected one handler per worker and one for serial cases, got %v", built)
+	}
+}
+
+func TestCheckParallelIsolation(t *testing.T) {
+	tests := []struct {
+		name    string
+		cases   []internal.TestCase
+		wantErr bool
+	}{
+		{"no isolation", []internal.TestCase{{Name: "a"}, {Name: "b", Isolation: "none"}}, false},
+		{"serial transaction", []internal.TestCase{{Name: "a", Serial: true, Isolation: "transaction"}}, false},
+		{"parallel transaction", []internal.TestCase{{Name: "a"}, {Name: "b", Isolation: "transaction"}}, true},
+		{"parallel snapshot", []internal.TestCase{{Name: "a", Isolation: "snapshot"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkParallelIsolation(tt.cases)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error: %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRun_ParallelFakerSeed(t *testing.T) {
+	t.Setenv(FakerSeedEnv, "")
+
+	var cases strings.Builder
+	for i := 0; i < 6; i++ {
+		fmt.Fprintf(&cases, `
+- name: case%d
+  variables:
+    email: "{{faker.email}}"
+  steps:
+    - name: first
+      request: {method: GET, path: "/case%d/{{faker.uuid}}?email={{email}}"}
+      response: {status: 200}
+    - name: second
+      request: {method: GET, path: "/case%d/{{faker.int(1,1000000)}}"}
+      response: {status: 200}
+`, i, i, i)
+	}
+	casesDir := writeCases(t, cases.String())
+
+	run := func() map[string][]string {
+		var mu sync.Mutex
+		seen := map[string][]string{}
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Vary the timing so that cases interleave differently in every run
+			time.Sleep(time.Duration(rand.IntN(5)) * time.Millisecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			name := strings.Split(r.URL.Path, "/")[1]
+			seen[name] = append(seen[name], r.URL.RequestURI())
+			w.WriteHeader(http.StatusOK)
+		})
+
+		Run(t, &Config{
+			Handler:   handler,
+			CasesDir:  casesDir,
+			Parallel:  3,
+			FakerSeed: 42,
+		})
+
+		return seen
+	}
+
+	first := run()
+	second := run()
+	if len(first) != 6 {
+		t.Fatalf("Expected requests from 6 cases, got %v", first)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same values for the same seed, got %v and %v", first, second)
+	}
+}
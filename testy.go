@@ -1,7 +1,12 @@
 package testy
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"net/http"
+	"os"
+	"strconv"
 	"testing"
 
 	"github.com/rom8726/pgfixtures"
@@ -9,18 +14,104 @@ import (
 	"github.com/rom8726/testy/v2/internal"
 )
 
+// RenderMode defines how unresolved placeholders are handled
+type RenderMode = internal.RenderMode
+
+const (
+	// RenderModePermissive leaves unresolved placeholders unchanged
+	RenderModePermissive = internal.RenderModePermissive
+	// RenderModeStrict fails the step on unresolved placeholders
+	RenderModeStrict = internal.RenderModeStrict
+)
+
+// Database runs fixtures, SQL hooks and dbChecks. Implement it to plug in a
+// client that does not use database/sql, such as a pgx pool
+type Database = internal.Database
+
+// SQLite is the DBType for a caller-provided SQLite *sql.DB
+const SQLite = internal.SQLite
+
+// IsolationMode selects how the database changes of a case are undone
+type IsolationMode = internal.IsolationMode
+
+const (
+	// IsolationNone leaves the changes of a case in place (default)
+	IsolationNone = internal.IsolationNone
+	// IsolationTransaction runs every case in a transaction that is rolled
+	// back afterwards. The handler under test is built by TxHandler
+	IsolationTransaction = internal.IsolationTransaction
+	// IsolationSnapshot restores a PostgreSQL snapshot of the database,
+	// taken after the suite fixtures are loaded, after every case
+	IsolationSnapshot = internal.IsolationSnapshot
+)
+
+// FakerSeedEnv overrides Config.FakerSeed
+const FakerSeedEnv = "TESTY_FAKER_SEED"
+
 type Config struct {
-	Handler     http.Handler
+	Handler http.Handler
+	// BaseURL switches the runner to remote mode: requests are sent over the
+	// network to a live service instead of Handler. Mutually exclusive with Handler.
+	BaseURL string
+	// HTTPClient is used in remote mode. Defaults to a client that does not
+	// follow redirects, so 3xx responses are asserted as returned, and times
+	// out after 30 seconds.
+	HTTPClient *http.Client
+
 	DBType      pgfixtures.DatabaseType
 	CasesDir    string
 	FixturesDir string
 	ConnStr     string
 	MockManager *MockManager
 
+	// DB is used instead of opening ConnStr, e.g. a pool wrapped with tracing
+	// or an in-memory SQLite database. DBType selects the SQL dialect.
+	// Fixtures are loaded by pgfixtures when ConnStr is also set and by a
+	// built-in loader otherwise.
+	DB *sql.DB
+	// Database replaces DB with a custom adapter
+	Database Database
+
+	// Fixtures are loaded once before the first case, e.g. reference data
+	// shared by all cases. Names are relative to FixturesDir.
+	Fixtures []string
+	// Isolation undoes the database changes of every case. Cases can
+	// override it with `isolation`.
+	Isolation IsolationMode
+	// TxHandler builds the handler under test bound to the transaction of a
+	// case, e.g. a repository created on tx instead of the pool. Required for
+	// transaction isolation; Handler is then only used by cases that opt out.
+	TxHandler func(tx *sql.Tx) (http.Handler, error)
+
 	BeforeReq func() error
 	AfterReq  func() error
 
+	// RenderMode controls unresolved placeholders in requests, expected
+	// responses, hooks and dbChecks. Cases can override it with `renderMode`.
+	RenderMode RenderMode
+
+	// FakerSeed makes {{faker.*}} values reproducible. Zero picks a random
+	// seed. The seed in use is logged on every run and can be overridden
+	// with the TESTY_FAKER_SEED environment variable to replay a failure.
+	FakerSeed int64
+	// Fakers registers custom generators for {{faker.<name>}} placeholders,
+	// e.g. {{faker.iban}} or {{faker.tenantSlug("acme")}}. Arguments of a
+	// call are passed as strings. A custom generator replaces a built-in one
+	// with the same name.
+	Fakers map[string]func(args ...string) (string, error)
+
 	JUnitReport string
+
+	// Parallel runs test cases concurrently on up to Parallel workers.
+	// Each worker gets its own PostgreSQL database cloned from ConnStr (used
+	// as a template) and its own mock servers. Cases with `serial: true` run
+	// first, one by one, against the original database. Other databases are
+	// not supported.
+	Parallel int
+	// WorkerHandler builds the handler under test for a parallel worker,
+	// wired to the worker's database and mock servers. Without Handler it
+	// also builds the handler of serial cases, after the workers are cloned.
+	WorkerHandler func(w Worker) (http.Handler, error)
 }
 
 func Run(t *testing.T, cfg *Config) {
@@ -30,6 +121,12 @@ func Run(t *testing.T, cfg *Config) {
 		t.Fatalf("Configuration validation failed: %v", err)
 	}
 
+	seed, err := fakerSeed(cfg.FakerSeed)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	t.Logf("faker seed: %d (set %s=%d to reproduce)", seed, FakerSeedEnv, seed)
+
 	cases, err := internal.LoadTestCases(cfg.CasesDir)
 	if err != nil {
 		// Use the error directly since it's already wrapped by LoadTestCases
@@ -41,20 +138,102 @@ func Run(t *testing.T, cfg *Config) {
 		mocks = cfg.MockManager.internalInstances()
 	}
 
-	results := make([]internal.TestCaseResult, 0, len(cases))
+	handler := cfg.Handler
+	if cfg.BaseURL != "" {
+		handler = internal.NewRemoteHandler(cfg.BaseURL, cfg.HTTPClient)
+	}
 
-	for _, tc := range cases {
-		cfgInternal := internal.Config{
-			DBType:      cfg.DBType,
-			ConnStr:     cfg.ConnStr,
-			FixturesDir: cfg.FixturesDir,
-			Mocks:       mocks,
-			BeforeReq:   cfg.BeforeReq,
-			AfterReq:    cfg.AfterReq,
+	database := cfg.Database
+	if database == nil && cfg.DB != nil {
+		database = internal.NewSQLDatabase(cfg.DB, cfg.DBType, cfg.ConnStr)
+	}
+
+	isolation, err := internal.ParseIsolationMode(string(cfg.Isolation))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	base := internal.Config{
+		DBType:      cfg.DBType,
+		ConnStr:     cfg.ConnStr,
+		FixturesDir: cfg.FixturesDir,
+		Mocks:       mocks,
+		Database:    database,
+		Isolation:   isolation,
+		SQLDB:       cfg.DB,
+		TxHandler:   cfg.TxHandler,
+		BeforeReq:   cfg.BeforeReq,
+		AfterReq:    cfg.AfterReq,
+		FakerSeed:   seed,
+
+		RenderOptions: internal.RenderOptions{
+			Mode:  cfg.RenderMode,
+			Faker: fakerRegistry(cfg.Fakers),
+		},
+	}
+
+	if len(cfg.Fixtures) > 0 {
+		loadSuiteFixtures(t, cfg, database)
+	}
+
+	if cfg.TxHandler != nil && base.SQLDB == nil {
+		sqlDB, err := internal.OpenDB(cfg.DBType, cfg.ConnStr)
+		if err != nil {
+			t.Fatalf("failed to open database for transactions: %v", err)
+		}
+		defer func() { _ = sqlDB.Close() }()
+		base.SQLDB = sqlDB
+	}
+
+	if usesSnapshots(isolation, cases) && cfg.ConnStr != "" && cfg.DBType == pgfixtures.PostgreSQL {
+		base.Snapshot, err = takeSnapshot(t, cfg.ConnStr)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+
+	var pool *workerPool
+	if cfg.Parallel > 1 {
+		if err := checkParallelIsolation(cases); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		pool, err = startWorkers(t, cfg, handler, base)
+		if pool != nil {
+			defer pool.stop(t)
+		}
+		if err != nil {
+			t.Fatalf("failed to start parallel workers: %+v", err)
 		}
 
-		res := internal.RunSingle(t, cfg.Handler, tc, &cfgInternal)
-		results = append(results, res)
+		if handler == nil {
+			handler, err = serialHandler(cfg, cases)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+	}
+
+	results := make([]internal.TestCaseResult, len(cases))
+
+	for i, tc := range cases {
+		if pool != nil && !tc.Serial {
+			continue
+		}
+
+		cfgInternal := base
+		results[i] = internal.RunSingle(t, handler, tc, &cfgInternal)
+	}
+
+	// Parallel cases are grouped so that Run returns only after all of them finish
+	if pool != nil {
+		t.Run("parallel", func(t *testing.T) {
+			for i, tc := range cases {
+				if !tc.Serial {
+					internal.RunParallel(t, tc, pool.acquire, &results[i])
+				}
+			}
+		})
 	}
 
 	if cfg.JUnitReport != "" {
@@ -65,3 +244,90 @@ func Run(t *testing.T, cfg *Config) {
 		}
 	}
 }
+
+// loadSuiteFixtures loads Config.Fixtures into the suite database
+func loadSuiteFixtures(t *testing.T, cfg *Config, database Database) {
+	t.Helper()
+
+	if database == nil && cfg.ConnStr != "" {
+		sqlDB, err := internal.OpenDB(cfg.DBType, cfg.ConnStr)
+		if err != nil {
+			t.Fatalf("failed to open database for suite fixtures: %v", err)
+		}
+		defer func() { _ = sqlDB.Close() }()
+		database = internal.NewSQLDatabase(sqlDB, cfg.DBType, cfg.ConnStr)
+	}
+
+	internal.LoadFixtures(t, database, cfg.FixturesDir, cfg.Fixtures)
+}
+
+// usesSnapshots reports whether the suite or any case uses snapshot isolation
+func usesSnapshots(isolation IsolationMode, cases []internal.TestCase) bool {
+	if isolation == IsolationSnapshot {
+		return true
+	}
+	for _, tc := range cases {
+		if tc.Isolation == string(IsolationSnapshot) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// takeSnapshot copies the suite database and drops the copy when the test ends
+func takeSnapshot(t *testing.T, connStr string) (string, error) {
+	t.Helper()
+
+	dbName, err := internal.PostgresDBName(connStr)
+	if err != nil {
+		return "", err
+	}
+
+	snapshot := dbName + "_testy_snapshot"
+	if err := internal.SnapshotPostgresDatabase(t.Context(), connStr, snapshot); err != nil {
+		return "", err
+	}
+
+	t.Cleanup(func() {
+		if err := internal.DropPostgresDatabase(context.Background(), connStr, snapshot); err != nil {
+			t.Logf("%+v", err)
+		}
+	})
+
+	return snapshot, nil
+}
+
+// fakerRegistry returns the built-in generators extended with custom ones,
+// or nil to use the defaults
+func fakerRegistry(fakers map[string]func(args ...string) (string, error)) *internal.FakerRegistry {
+	if len(fakers) == 0 {
+		return nil
+	}
+
+	registry := internal.NewFakerRegistry()
+	for name, fn := range fakers {
+		registry.RegisterFunc(name, fn)
+	}
+
+	return registry
+}
+
+// fakerSeed returns the seed for faker generators: the environment override,
+// the configured seed or a random one
+func fakerSeed(configured int64) (int64, error) {
+	if env := os.Getenv(FakerSeedEnv); env != "" {
+		seed, err := strconv.ParseInt(env, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q: %w", FakerSeedEnv, env, err)
+		}
+
+		return seed, nil
+	}
+
+	if configured != 0 {
+		return configured, nil
+	}
+
+	return internal.NewFakerSeed(), nil
+}
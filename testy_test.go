@@ -0,0 +1,457 @@
+package testy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writeCases writes a case file into a new cases directory
+func writeCases(t *testing.T, cases string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cases.testy.yml"), []byte(cases), 0o644); err != nil {
+		t.Fatalf("Failed to write cases: %v", err)
+	}
+
+	return dir
+}
+
+func TestRun_RemoteRedirect(t *testing.T) {
+	casesDir := writeCases(t, `
+- name: redirect
+  steps:
+    - name: login required
+      request: {method: GET, path: /account}
+      response:
+        status: 302
+        headers: {Location: /login}
+`)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/account" {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	Run(t, &Config{
+		BaseURL:  srv.URL,
+		CasesDir: casesDir,
+	})
+}
+
+func TestRun_CustomFakers(t *testing.T) {
+	casesDir := writeCases(t, `
+- name: custom faker
+  steps:
+    - name: get tenant
+      request: {method: GET, path: "/tenants/{{faker.tenantSlug(acme)}}"}
+      response: {status: 200}
+`)
+
+	var path string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	})
+
+	Run(t, &Config{
+		Handler:  handler,
+		CasesDir: casesDir,
+		Fakers: map[string]func(args ...string) (string, error){
+			"tenantSlug": func(args ...string) (string, error) {
+				return strings.Join(args, "-") + "-tenant", nil
+			},
+		},
+	})
+
+	if path != "/tenants/acme-tenant" {
+		t.Errorf("Expected custom faker in path, got %s", path)
+	}
+}
+
+// fakeDatabase records the statements sent to a custom Database
+type fakeDatabase struct {
+	execs    []string
+	fixtures []string
+}
+
+func (d *fakeDatabase) Exec(_ context.Context, query string, _ ...any) error {
+	d.execs = append(d.execs, query)
+
+	return nil
+}
+
+func (d *fakeDatabase) Query(_ context.Context, query string, _ ...any) ([]map[string]any, error) {
+	return []map[string]any{{"name": "Alice"}}, nil
+}
+
+func (d *fakeDatabase) LoadFixture(_ context.Context, path string) error {
+	d.fixtures = append(d.fixtures, filepath.Base(path))
+
+	return nil
+}
+
+func TestRun_CustomDatabase(t *testing.T) {
+	casesDir := writeCases(t, `
+- name: custom database
+  fixtures: [users]
+  setup:
+    - sql: "UPDATE users SET name = 'Alice'"
+  steps:
+    - name: get user
+      request: {method: GET, path: /users/1}
+      response: {status: 200}
+      dbChecks:
+        - query: SELECT name FROM users
+          result: [{name: Alice}]
+          extract:
+            user: {columns: [name]}
+    - name: find user by name
+      request: {method: GET, path: "/users/by-name/{{user.name}}"}
+      response: {status: 200}
+`)
+
+	var paths []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	db := &fakeDatabase{}
+	Run(t, &Config{
+		Handler:     handler,
+		CasesDir:    casesDir,
+		FixturesDir: t.TempDir(),
+		Database:    db,
+	})
+
+	if !reflect.DeepEqual(db.fixtures, []string{"users.yml"}) {
+		t.Errorf("Expected fixture users.yml to be loaded, got %v", db.fixtures)
+	}
+	if !reflect.DeepEqual(db.execs, []string{"UPDATE users SET name = 'Alice'"}) {
+		t.Errorf("Expected setup hook to run on the custom database, got %v", db.execs)
+	}
+	if len(paths) != 2 || paths[1] != "/users/by-name/Alice" {
+		t.Errorf("Expected extracted name in second request, got %v", paths)
+	}
+}
+
+// txLog is a database/sql connector recording statements and transaction
+// outcomes. Queries return a single row {name: Alice}
+type txLog struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *txLog) record(entry string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+func (l *txLog) Connect(context.Context) (driver.Conn, error) { return &txLogConn{log: l}, nil }
+func (l *txLog) Driver() driver.Driver                        { return nil }
+
+type txLogConn struct{ log *txLog }
+
+func (c *txLogConn) Prepare(query string) (driver.Stmt, error) {
+	return &txLogStmt{log: c.log, query: query}, nil
+}
+func (c *txLogConn) Close() error              { return nil }
+func (c *txLogConn) Begin() (driver.Tx, error) { c.log.record("BEGIN"); return c, nil }
+func (c *txLogConn) Commit() error             { c.log.record("COMMIT"); return nil }
+func (c *txLogConn) Rollback() error           { c.log.record("ROLLBACK"); return nil }
+
+type txLogStmt struct {
+	log   *txLog
+	query string
+}
+
+func (s *txLogStmt) Close() error  { return nil }
+func (s *txLogStmt) NumInput() int { return -1 }
+func (s *txLogStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.log.record(s.query)
+	return driver.RowsAffected(1), nil
+}
+func (s *txLogStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.log.record(s.query)
+	return &txLogRows{}, nil
+}
+
+type txLogRows struct{ done bool }
+
+func (r *txLogRows) Columns() []string { return []string{"name"} }
+func (r *txLogRows) Close() error      { return nil }
+func (r *txLogRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = "Alice"
+
+	return nil
+}
+
+func TestRun_TransactionIsolation(t *testing.T) {
+	casesDir := writeCases(t, `
+- name: isolated case
+  fixtures: [users]
+  setup:
+    - sql: DELETE FROM sessions
+  steps:
+    - name: touch user
+      request: {method: POST, path: /users/1/touch}
+      response: {status: 204}
+      dbChecks:
+        - query: SELECT name FROM users
+          result: [{name: Alice}]
+`)
+
+	fixturesDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(fixturesDir, "users.yml"), []byte("users:\n  - name: Alice\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	log := &txLog{}
+	db := sql.OpenDB(log)
+	defer db.Close()
+
+	Run(t, &Config{
+		CasesDir:    casesDir,
+		FixturesDir: fixturesDir,
+		DB:          db,
+		DBType:      SQLite,
+		Isolation:   IsolationTransaction,
+		TxHandler: func(tx *sql.Tx) (http.Handler, error) {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, err := tx.Exec("UPDATE users SET touched = 1"); err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}), nil
+		},
+	})
+
+	expected := []string{
+		"BEGIN",
+		"DELETE FROM sessions",
+		`DELETE FROM "users"`,
+		`INSERT INTO "users" ("name") VALUES (?)`,
+		"UPDATE users SET touched = 1",
+		"SELECT name FROM users",
+		"ROLLBACK",
+	}
+	if !reflect.DeepEqual(log.entries, expected) {
+		t.Errorf("Expected statements %q, got %q", expected, log.entries)
+	}
+}
+
+func TestRun_MockRoutesPerCase(t *testing.T) {
+	casesDir := writeCases(t, `
+- name: first
+  mockServers:
+    notifier:
+      routes:
+        - {method: POST, path: /send, response: {status: 202}}
+  steps:
+    - name: notify
+      request: {method: POST, path: /notify}
+      response: {status: 202}
+  mockCalls:
+    - {mock: notifier, count: 1, expect: {method: POST, path: /send}}
+
+- name: second
+  mockServers:
+    notifier:
+      routes:
+        - {method: POST, path: /send, response: {status: 202}}
+  steps:
+    - name: notify
+      request: {method: POST, path: /notify}
+      response: {status: 202}
+    - name: notifier down
+      mockServers:
+        notifier:
+          routes:
+            - {method: POST, path: /send, response: {status: 503}}
+      request: {method: POST, path: /notify}
+      response: {status: 503}
+      mockCalls:
+        - {mock: notifier, count: 1, expect: {method: POST, path: /send}}
+  mockCalls:
+    - {mock: notifier, count: 2, expect: {method: POST, path: /send}}
+`)
+
+	mocks, err := StartMockManager("notifier")
+	if err != nil {
+		t.Fatalf("Failed to start mocks: %v", err)
+	}
+	defer mocks.StopAll()
+
+	// Calls already recorded when each request arrives
+	var seen []int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, len(mocks.Calls("notifier")))
+
+		resp, err := http.Post(mocks.URL("notifier")+"/send", "application/json", nil)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+	})
+
+	Run(t, &Config{
+		Handler:     handler,
+		CasesDir:    casesDir,
+		MockManager: mocks,
+	})
+
+	// The second case starts with an empty call log
+	if expected := []int{0, 0, 1}; !reflect.DeepEqual(seen, expected) {
+		t.Errorf("Expected recorded calls %v before each request, got %v", expected, seen)
+	}
+	if calls := mocks.Calls("notifier"); len(calls) != 2 {
+		t.Errorf("Expected the 2 calls of the last case, got %d", len(calls))
+	}
+}
+
+func TestRun_ConcurrentMockCalls(t *testing.T) {
+	casesDir := writeCases(t, `
+- name: fan out
+  mockServers:
+    notifier:
+      routes:
+        - {method: POST, path: /send, response: {status: 202}}
+  steps:
+    - name: broadcast
+      request: {method: POST, path: /broadcast}
+      response: {status: 204}
+  mockCalls:
+    - {mock: notifier, count: 10, expect: {method: POST, path: /send}}
+`)
+
+	mocks, err := StartMockManager("notifier")
+	if err != nil {
+		t.Fatalf("Failed to start mocks: %v", err)
+	}
+	defer mocks.StopAll()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				body := strings.NewReader(fmt.Sprintf(`{"n":%d}`, i))
+				resp, err := http.Post(mocks.URL("notifier")+"/send", "application/json", body)
+				if err == nil {
+					resp.Body.Close()
+				}
+			}()
+		}
+		wg.Wait()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	Run(t, &Config{
+		Handler:     handler,
+		CasesDir:    casesDir,
+		MockManager: mocks,
+	})
+
+	if calls := mocks.Calls("notifier"); len(calls) != 10 {
+		t.Errorf("Expected 10 calls, got %d", len(calls))
+	}
+	if calls := mocks.Calls("unknown"); calls != nil {
+		t.Errorf("Expected nil for an unknown mock, got %v", calls)
+	}
+}
+
+func TestRun_MockResponseTemplates(t *testing.T) {
+	casesDir := writeCases(t, `
+- name: echo
+  variables:
+    tenant: acme
+  mockServers:
+    profiles:
+      routes:
+        - method: GET
+          path: /profiles/:id
+          response:
+            status: 200
+            json: '{"id": "{{request.path.id}}", "tenant": "{{tenant}}", "trace": "{{request.headers.X-Request-Id}}"}'
+  steps:
+    - name: proxy
+      request: {method: GET, path: /users/42}
+      response:
+        status: 200
+        json: '{"id": "42", "tenant": "acme", "trace": "trace-42"}'
+`)
+
+	mocks, err := StartMockManager("profiles")
+	if err != nil {
+		t.Fatalf("Failed to start mocks: %v", err)
+	}
+	defer mocks.StopAll()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/users/")
+		req, _ := http.NewRequest(http.MethodGet, mocks.URL("profiles")+"/profiles/"+id, nil)
+		req.Header.Set("X-Request-Id", "trace-"+id)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	})
+
+	Run(t, &Config{
+		Handler:     handler,
+		CasesDir:    casesDir,
+		MockManager: mocks,
+	})
+}
+
+func TestFakerSeed(t *testing.T) {
+	t.Setenv(FakerSeedEnv, "")
+
+	seed, err := fakerSeed(42)
+	if err != nil || seed != 42 {
+		t.Errorf("Expected configured seed 42, got %d, %v", seed, err)
+	}
+
+	t.Setenv(FakerSeedEnv, "7")
+	seed, err = fakerSeed(42)
+	if err != nil || seed != 7 {
+		t.Errorf("Expected seed 7 from environment, got %d, %v", seed, err)
+	}
+
+	t.Setenv(FakerSeedEnv, "abc")
+	if _, err := fakerSeed(42); err == nil {
+		t.Error("Expected error for invalid seed in environment")
+	}
+}
@@ -3,10 +3,18 @@ package testy
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/rom8726/pgfixtures"
 )
 
+// fakerNameRe matches names usable in {{faker.<name>}} placeholders
+var fakerNameRe = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
 type ValidationError struct {
 	Field   string
 	Message string
@@ -19,10 +27,33 @@ func (e ValidationError) Error() string {
 func (c *Config) Validate() error {
 	var validationErrors []ValidationError
 
-	if c.Handler == nil {
+	if c.Handler == nil && c.BaseURL == "" && c.TxHandler == nil && (c.Parallel <= 1 || c.WorkerHandler == nil) {
 		validationErrors = append(validationErrors, ValidationError{
 			Field:   "Handler",
-			Message: "http.Handler is required",
+			Message: "http.Handler or BaseURL is required",
+		})
+	}
+
+	if c.Handler != nil && c.BaseURL != "" {
+		validationErrors = append(validationErrors, ValidationError{
+			Field:   "BaseURL",
+			Message: "BaseURL and Handler are mutually exclusive",
+		})
+	}
+
+	if c.BaseURL != "" {
+		if u, err := url.Parse(c.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "BaseURL",
+				Message: fmt.Sprintf("invalid base URL: %s", c.BaseURL),
+			})
+		}
+	}
+
+	if c.HTTPClient != nil && c.BaseURL == "" {
+		validationErrors = append(validationErrors, ValidationError{
+			Field:   "HTTPClient",
+			Message: "HTTPClient is only used together with BaseURL",
 		})
 	}
 
@@ -59,10 +90,24 @@ func (c *Config) Validate() error {
 		}
 	}
 
-	if c.FixturesDir != "" && c.ConnStr == "" {
+	if c.FixturesDir != "" && c.ConnStr == "" && c.DB == nil && c.Database == nil {
 		validationErrors = append(validationErrors, ValidationError{
 			Field:   "ConnStr",
-			Message: "database connection string is required when FixturesDir is provided",
+			Message: "database connection string is required when FixturesDir is provided, unless DB or Database is set",
+		})
+	}
+
+	if c.DB != nil && c.Database != nil {
+		validationErrors = append(validationErrors, ValidationError{
+			Field:   "Database",
+			Message: "DB and Database are mutually exclusive",
+		})
+	}
+
+	if c.DB != nil && len(c.DBType) == 0 {
+		validationErrors = append(validationErrors, ValidationError{
+			Field:   "DBType",
+			Message: "DBType must be specified when DB is provided",
 		})
 	}
 
@@ -73,6 +118,88 @@ func (c *Config) Validate() error {
 		})
 	}
 
+	switch c.DBType {
+	case "", pgfixtures.PostgreSQL, pgfixtures.MySQL:
+	case SQLite:
+		if c.DB == nil && c.Database == nil {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "DB",
+				Message: "SQLite requires DB, no SQLite driver is bundled",
+			})
+		}
+	default:
+		validationErrors = append(validationErrors, ValidationError{
+			Field:   "DBType",
+			Message: fmt.Sprintf("unsupported database type: %s", c.DBType),
+		})
+	}
+
+	if c.RenderMode != RenderModePermissive && c.RenderMode != RenderModeStrict {
+		validationErrors = append(validationErrors, ValidationError{
+			Field:   "RenderMode",
+			Message: fmt.Sprintf("unknown render mode: %d", c.RenderMode),
+		})
+	}
+
+	fakerNames := make([]string, 0, len(c.Fakers))
+	for name := range c.Fakers {
+		fakerNames = append(fakerNames, name)
+	}
+	sort.Strings(fakerNames)
+
+	for _, name := range fakerNames {
+		if !fakerNameRe.MatchString(name) {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "Fakers",
+				Message: fmt.Sprintf("invalid faker name: %q", name),
+			})
+		} else if c.Fakers[name] == nil {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "Fakers",
+				Message: fmt.Sprintf("faker %s has no function", name),
+			})
+		}
+	}
+
+	if c.Parallel < 0 {
+		validationErrors = append(validationErrors, ValidationError{
+			Field:   "Parallel",
+			Message: "Parallel must not be negative",
+		})
+	}
+
+	if c.Parallel > 1 {
+		if c.DB != nil || c.Database != nil {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "Parallel",
+				Message: "parallel execution needs ConnStr to clone worker databases, DB and Database cannot be shared",
+			})
+		}
+
+		if c.ConnStr != "" && c.DBType != pgfixtures.PostgreSQL {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "Parallel",
+				Message: "parallel database isolation is supported for PostgreSQL only, per-worker schemas for other databases are not implemented",
+			})
+		}
+
+		if (c.ConnStr != "" || c.MockManager != nil) && c.WorkerHandler == nil {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "WorkerHandler",
+				Message: "WorkerHandler is required when Parallel is used with a database or mocks",
+			})
+		}
+	}
+
+	validationErrors = append(validationErrors, c.validateIsolation()...)
+
+	if c.WorkerHandler != nil && c.BaseURL != "" {
+		validationErrors = append(validationErrors, ValidationError{
+			Field:   "WorkerHandler",
+			Message: "WorkerHandler and BaseURL are mutually exclusive",
+		})
+	}
+
 	if c.JUnitReport != "" {
 		dir := filepath.Dir(c.JUnitReport)
 		if dir != "" && dir != "." {
@@ -106,3 +233,76 @@ func (c *Config) Validate() error {
 
 	return nil
 }
+
+// validateIsolation checks the suite fixtures and the isolation settings
+func (c *Config) validateIsolation() []ValidationError {
+	var validationErrors []ValidationError
+
+	if len(c.Fixtures) > 0 {
+		if c.FixturesDir == "" {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "Fixtures",
+				Message: "FixturesDir is required when Fixtures are provided",
+			})
+		}
+		if c.ConnStr == "" && c.DB == nil && c.Database == nil {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "Fixtures",
+				Message: "a database (ConnStr, DB or Database) is required when Fixtures are provided",
+			})
+		}
+	}
+
+	switch c.Isolation {
+	case "", IsolationNone:
+	case IsolationTransaction:
+		if c.TxHandler == nil {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "TxHandler",
+				Message: "TxHandler is required for transaction isolation",
+			})
+		}
+	case IsolationSnapshot:
+		if c.ConnStr == "" || c.DBType != pgfixtures.PostgreSQL {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "Isolation",
+				Message: "snapshot isolation requires a PostgreSQL ConnStr",
+			})
+		}
+	default:
+		validationErrors = append(validationErrors, ValidationError{
+			Field:   "Isolation",
+			Message: fmt.Sprintf("unknown isolation mode: %s", c.Isolation),
+		})
+	}
+
+	if c.Isolation != "" && c.Isolation != IsolationNone && c.Parallel > 1 {
+		validationErrors = append(validationErrors, ValidationError{
+			Field:   "Isolation",
+			Message: "isolation cannot be combined with Parallel, workers already use separate databases",
+		})
+	}
+
+	if c.TxHandler != nil {
+		if c.ConnStr == "" && c.DB == nil {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "TxHandler",
+				Message: "TxHandler requires ConnStr or DB to start transactions",
+			})
+		}
+		if c.BaseURL != "" {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "TxHandler",
+				Message: "TxHandler and BaseURL are mutually exclusive",
+			})
+		}
+		if c.Database != nil {
+			validationErrors = append(validationErrors, ValidationError{
+				Field:   "TxHandler",
+				Message: "TxHandler cannot be used with Database, transactions need DB or ConnStr",
+			})
+		}
+	}
+
+	return validationErrors
+}